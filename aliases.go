@@ -0,0 +1,58 @@
+package avro
+
+// FullAliases returns the record's aliases as full names. Aliases are resolved
+// like the names of types defined inside the record: an alias without a dot is
+// in the record's namespace, so the alias "Person" of the record
+// "com.example.User" is "com.example.Person", while the alias "legacy.User" is
+// already a full name.
+func (r *Record) FullAliases() []string {
+	return fullAliases(r.Aliases, namespaceOf(r.Fullname()))
+}
+
+// FullAliases returns the enum's aliases as full names, resolved as for
+// Record.FullAliases.
+func (e *Enum) FullAliases() []string {
+	return fullAliases(e.Aliases, namespaceOf(e.Fullname()))
+}
+
+// FullAliases returns the fixed type's aliases as full names, resolved as for
+// Record.FullAliases.
+func (f *Fixed) FullAliases() []string {
+	return fullAliases(f.Aliases, namespaceOf(f.Fullname()))
+}
+
+// HasName reports whether the schema is a named type, or a resolved reference
+// to one, whose full name or one of whose aliases is the full name. Aliases
+// are resolved against the type's namespace before they are compared, so a
+// type in a namespace matches its unqualified aliases only within it.
+func HasName(s Schema, fullname string) bool {
+	n, ok := deref(s).(Named)
+	if !ok {
+		return false
+	}
+	if n.Fullname() == fullname {
+		return true
+	}
+
+	a, ok := n.(interface{ FullAliases() []string })
+	if !ok {
+		return false
+	}
+	for _, alias := range a.FullAliases() {
+		if alias == fullname {
+			return true
+		}
+	}
+	return false
+}
+
+func fullAliases(aliases []string, namespace string) []string {
+	if aliases == nil {
+		return nil
+	}
+	r := make([]string, len(aliases))
+	for i, a := range aliases {
+		r[i] = fullname(a, namespace)
+	}
+	return r
+}
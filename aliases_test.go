@@ -0,0 +1,66 @@
+package avro
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestFullAliases(t *testing.T) {
+	s := MustParse(`{
+		"type": "record",
+		"name": "User",
+		"namespace": "com.example",
+		"aliases": ["Person", "legacy.User"],
+		"fields": [
+			{"name": "status", "type": {"type": "enum", "name": "Status", "aliases": ["State"], "symbols": ["A"]}},
+			{"name": "hash", "type": {"type": "fixed", "name": "other.Hash", "aliases": ["Digest"], "size": 16}}
+		]
+	}`)
+	r := s.(*Record)
+
+	tests := []struct {
+		Aliases []string
+		Exp     []string
+	}{
+		{r.FullAliases(), []string{"com.example.Person", "legacy.User"}},
+		{r.Fields[0].Type.(*Enum).FullAliases(), []string{"com.example.State"}},
+		{r.Fields[1].Type.(*Fixed).FullAliases(), []string{"other.Digest"}},
+		{(&Record{Name: "R"}).FullAliases(), nil},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			if !reflect.DeepEqual(test.Aliases, test.Exp) {
+				t.Errorf("expected %v, got %v", test.Exp, test.Aliases)
+			}
+		})
+	}
+}
+
+func TestHasName(t *testing.T) {
+	user := &Record{Name: "User", Namespace: "com.example", Aliases: []string{"Person", "legacy.User"}}
+
+	tests := []struct {
+		Schema Schema
+		Name   string
+		Has    bool
+	}{
+		{user, "com.example.User", true},
+		{user, "com.example.Person", true},
+		{user, "legacy.User", true},
+		{user, "Person", false},
+		{user, "User", false},
+		{&Reference{Name: "com.example.User", Schema: user}, "com.example.Person", true},
+		{&Reference{Name: "com.example.User"}, "com.example.User", false},
+		{String, "string", false},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			if has := HasName(test.Schema, test.Name); has != test.Has {
+				t.Errorf("expected %v, got %v", test.Has, has)
+			}
+		})
+	}
+}
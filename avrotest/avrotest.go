@@ -0,0 +1,59 @@
+// Package avrotest provides helpers for testing code that builds Avro schemas.
+package avrotest
+
+import (
+	"bytes"
+	"testing"
+
+	avro "github.com/arcus/go-avro"
+)
+
+// RoundTripSchema checks that the schema survives serialization: it is
+// marshaled and unmarshaled again, and the result must be equal to the
+// original, marshal to the same bytes and have the same fingerprint. Failures
+// are reported to t.
+//
+// Parsing fills in the namespace a nested named type inherits, so such types
+// must set it explicitly to survive unchanged.
+func RoundTripSchema(t testing.TB, s avro.Schema) {
+	t.Helper()
+
+	b, err := avro.Marshal(s)
+	if err != nil {
+		t.Fatalf("marshal: %s", err)
+		return
+	}
+
+	x, err := avro.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("unmarshal %s: %s", b, err)
+		return
+	}
+
+	if !avro.Equal(s, x) {
+		t.Errorf("schema changed by round trip:\n  before: %s\n  after:  %v", b, x)
+	}
+
+	b2, err := avro.Marshal(x)
+	if err != nil {
+		t.Fatalf("marshal after round trip: %s", err)
+		return
+	}
+	if !bytes.Equal(b, b2) {
+		t.Errorf("marshaled form changed by round trip:\n  before: %s\n  after:  %s", b, b2)
+	}
+
+	fp, err := avro.Fingerprint(s)
+	if err != nil {
+		t.Fatalf("fingerprint: %s", err)
+		return
+	}
+	fp2, err := avro.Fingerprint(x)
+	if err != nil {
+		t.Fatalf("fingerprint after round trip: %s", err)
+		return
+	}
+	if fp != fp2 {
+		t.Errorf("fingerprint changed by round trip: %x before, %x after", fp, fp2)
+	}
+}
@@ -0,0 +1,70 @@
+package avrotest
+
+import (
+	"fmt"
+	"testing"
+
+	avro "github.com/arcus/go-avro"
+)
+
+// recorder is a testing.TB that records failures instead of reporting them.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+}
+
+func TestRoundTripSchema(t *testing.T) {
+	tree := &avro.Record{Name: "Tree", Namespace: "com.example"}
+	tree.Fields = []*avro.Field{
+		{Name: "value", Type: avro.Union{avro.Null, avro.Date}},
+		{Name: "children", Type: &avro.Array{Items: &avro.Reference{Name: "com.example.Tree", Schema: tree}}},
+		{Name: "kind", Type: &avro.Enum{Name: "Kind", Namespace: "com.example", Symbols: []string{"LEAF", "BRANCH"}}},
+		{Name: "price", Type: &avro.Decimal{Precision: 9, Scale: 2}},
+	}
+
+	RoundTripSchema(t, tree)
+	RoundTripSchema(t, avro.Union{avro.Null, avro.String})
+}
+
+func TestRoundTripSchemaFailure(t *testing.T) {
+	// A field whose order is invalid cannot be marshaled.
+	r := &recorder{TB: t}
+	RoundTripSchema(r, &avro.Record{
+		Name:   "R",
+		Fields: []*avro.Field{{Name: "a", Type: avro.Int, Order: "sideways"}},
+	})
+	if len(r.failures) != 1 {
+		t.Errorf("expected 1 failure, got %q", r.failures)
+	}
+
+	// A nested named type without its inherited namespace does not survive.
+	r = &recorder{TB: t}
+	RoundTripSchema(r, &avro.Record{
+		Name:      "R",
+		Namespace: "com.example",
+		Fields:    []*avro.Field{{Name: "a", Type: &avro.Fixed{Name: "F", Size: 4}}},
+	})
+	if len(r.failures) != 2 {
+		t.Errorf("expected 2 failures, got %q", r.failures)
+	}
+
+	// A record referring to an undefined type does not survive.
+	r = &recorder{TB: t}
+	RoundTripSchema(r, &avro.Record{
+		Name:   "R",
+		Fields: []*avro.Field{{Name: "a", Type: &avro.Reference{Name: "Missing"}}},
+	})
+	if len(r.failures) != 1 {
+		t.Errorf("expected 1 failure, got %q", r.failures)
+	}
+}
@@ -0,0 +1,59 @@
+package avro
+
+import (
+	"crypto/sha256"
+	"sync"
+)
+
+// SchemaCache parses encoded schemas, returning the schema already parsed for
+// identical bytes. Schemas are keyed by the SHA-256 hash of the bytes, so
+// encodings that differ only in whitespace or attribute order are parsed and
+// cached separately. Parse errors are not cached. The returned schemas are
+// shared and must not be modified. The zero value is ready to use and parses
+// like Unmarshal, and a SchemaCache is safe for concurrent use.
+type SchemaCache struct {
+	// Parser configures how schemas are parsed. It must not be changed
+	// after the first call to Get.
+	Parser Parser
+
+	mu      sync.RWMutex
+	schemas map[[sha256.Size]byte]Schema
+}
+
+// Get returns the schema encoded by the bytes, parsing it on the first call.
+func (c *SchemaCache) Get(b []byte) (Schema, error) {
+	key := sha256.Sum256(b)
+
+	c.mu.RLock()
+	s, ok := c.schemas[key]
+	c.mu.RUnlock()
+	if ok {
+		return s, nil
+	}
+
+	s, err := c.Parser.Parse(b)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Another call may have parsed the same bytes meanwhile, in which case
+	// its schema is kept so that all callers share one.
+	if x, ok := c.schemas[key]; ok {
+		return x, nil
+	}
+	if c.schemas == nil {
+		c.schemas = make(map[[sha256.Size]byte]Schema)
+	}
+	c.schemas[key] = s
+	return s, nil
+}
+
+// Len returns the number of cached schemas.
+func (c *SchemaCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.schemas)
+}
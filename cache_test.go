@@ -0,0 +1,76 @@
+package avro
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSchemaCache(t *testing.T) {
+	var c SchemaCache
+
+	b := []byte(`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}]}`)
+
+	s1, err := c.Get(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, err := c.Get(append([]byte(nil), b...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s1 != s2 {
+		t.Errorf("expected the cached schema for identical bytes")
+	}
+
+	// Different bytes are parsed separately, even if the schema is equal.
+	s3, err := c.Get([]byte(`{"type":"record","name":"R","fields":[{"name":"a","type":"int"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s3 == s1 || !Equal(s3, s1) {
+		t.Errorf("expected an equal but separately parsed schema")
+	}
+
+	// Errors are returned and not cached.
+	if _, err := c.Get([]byte(`"Missing"`)); err == nil {
+		t.Errorf("expected error")
+	}
+	if n := c.Len(); n != 2 {
+		t.Errorf("expected 2 cached schemas, got %d", n)
+	}
+}
+
+func TestSchemaCacheParser(t *testing.T) {
+	c := SchemaCache{Parser: Parser{DisallowUnknownFields: true}}
+	if _, err := c.Get([]byte(`{"type": "array", "item": "int"}`)); err == nil {
+		t.Errorf("expected unknown attribute error")
+	}
+}
+
+func TestSchemaCacheConcurrent(t *testing.T) {
+	var (
+		c       SchemaCache
+		wg      sync.WaitGroup
+		schemas = make([]Schema, 16)
+	)
+
+	for i := range schemas {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s, err := c.Get([]byte(`{"type": "map", "values": "long"}`))
+			if err != nil {
+				t.Error(err)
+			}
+			schemas[i] = s
+		}(i)
+	}
+	wg.Wait()
+
+	// All callers share the schema that was cached first.
+	for _, s := range schemas[1:] {
+		if s != schemas[0] {
+			t.Fatalf("expected all callers to share one schema")
+		}
+	}
+}
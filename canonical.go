@@ -0,0 +1,223 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// CanonicalForm returns the Parsing Canonical Form of the schema, which
+// removes everything that does not affect how data is read: docs, aliases,
+// sort orders, defaults, logical types and custom attributes are dropped,
+// primitives are written by name, names are replaced with full names and
+// attributes are written in a fixed order without whitespace. Schemas that
+// differ only in these respects have the same canonical form. Nothing is
+// reordered: union branches, record fields and enum symbols keep their order,
+// which determines how data is encoded, so swapping two of them changes the
+// canonical form.
+// https://avro.apache.org/docs/current/spec.html#Parsing+Canonical+Form+for+Schemas
+func CanonicalForm(s Schema) ([]byte, error) {
+	c := canonicalizer{defined: make(map[string]bool)}
+	if err := c.write(s, ""); err != nil {
+		return nil, err
+	}
+	return c.buf.Bytes(), nil
+}
+
+// Fingerprint returns the 64-bit Rabin fingerprint of the schema's canonical
+// form, as used to identify schemas in single-object encoding and registries.
+// https://avro.apache.org/docs/current/spec.html#schema_fingerprints
+func Fingerprint(s Schema) (uint64, error) {
+	b, err := CanonicalForm(s)
+	if err != nil {
+		return 0, err
+	}
+	return rabin(b), nil
+}
+
+// FingerprintEqual reports whether the schemas have the same fingerprint, which
+// is the notion of the same schema used by registries. Unlike Equal, docs,
+// aliases, defaults, logical types and custom attributes are ignored. Field
+// order is not: the canonical form keeps fields in the order they are defined.
+func FingerprintEqual(a, b Schema) (bool, error) {
+	fa, err := Fingerprint(a)
+	if err != nil {
+		return false, err
+	}
+	fb, err := Fingerprint(b)
+	if err != nil {
+		return false, err
+	}
+	return fa == fb, nil
+}
+
+// Key returns a string identifying the schema for use as a Go map key, such as
+// in a cache of per-schema codecs. It is the schema's canonical form, so like
+// FingerprintEqual it ignores docs, aliases, defaults, logical types and custom
+// attributes, and unlike a fingerprint distinct schemas never share a key.
+func Key(s Schema) (string, error) {
+	b, err := CanonicalForm(s)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// canonicalizer writes the canonical form of a schema. Named types are written
+// in full where they are first reached and by full name thereafter.
+type canonicalizer struct {
+	buf     bytes.Buffer
+	defined map[string]bool
+}
+
+func (c *canonicalizer) write(s Schema, namespace string) error {
+	switch x := s.(type) {
+	case Primitive:
+		c.writeString(string(x))
+
+	case *Record:
+		fn := fullname(x.Name, c.namespace(x.Namespace, namespace))
+		if c.reference(fn) {
+			return nil
+		}
+
+		c.buf.WriteString(`{"name":`)
+		c.writeString(fn)
+		c.buf.WriteString(`,"type":"record","fields":[`)
+		for i, f := range x.Fields {
+			if i > 0 {
+				c.buf.WriteByte(',')
+			}
+			c.buf.WriteString(`{"name":`)
+			c.writeString(f.Name)
+			c.buf.WriteString(`,"type":`)
+			if err := c.write(f.Type, namespaceOf(fn)); err != nil {
+				return err
+			}
+			c.buf.WriteByte('}')
+		}
+		c.buf.WriteString(`]}`)
+
+	case *Enum:
+		fn := fullname(x.Name, c.namespace(x.Namespace, namespace))
+		if c.reference(fn) {
+			return nil
+		}
+
+		c.buf.WriteString(`{"name":`)
+		c.writeString(fn)
+		c.buf.WriteString(`,"type":"enum","symbols":[`)
+		for i, sym := range x.Symbols {
+			if i > 0 {
+				c.buf.WriteByte(',')
+			}
+			c.writeString(sym)
+		}
+		c.buf.WriteString(`]}`)
+
+	case *Array:
+		c.buf.WriteString(`{"type":"array","items":`)
+		if err := c.write(x.Items, namespace); err != nil {
+			return err
+		}
+		c.buf.WriteByte('}')
+
+	case *Map:
+		c.buf.WriteString(`{"type":"map","values":`)
+		if err := c.write(x.Values, namespace); err != nil {
+			return err
+		}
+		c.buf.WriteByte('}')
+
+	case Union:
+		c.buf.WriteByte('[')
+		for i, t := range x {
+			if i > 0 {
+				c.buf.WriteByte(',')
+			}
+			if err := c.write(t, namespace); err != nil {
+				return err
+			}
+		}
+		c.buf.WriteByte(']')
+
+	case *Fixed:
+		fn := fullname(x.Name, c.namespace(x.Namespace, namespace))
+		if c.reference(fn) {
+			return nil
+		}
+
+		c.buf.WriteString(`{"name":`)
+		c.writeString(fn)
+		c.buf.WriteString(`,"type":"fixed","size":`)
+		c.buf.WriteString(strconv.Itoa(x.Size))
+		c.buf.WriteByte('}')
+
+	case *Reference:
+		// A type defined elsewhere, such as in another file, is written in
+		// full where it is first reached.
+		if x.Schema != nil && !c.defined[x.Name] {
+			return c.write(x.Schema, namespaceOf(x.Name))
+		}
+		c.writeString(x.Name)
+
+	default:
+		// Logical types are written as their underlying type.
+		u := Underlying(s)
+		if u == s {
+			return fmt.Errorf("avroschema: unsupported schema type %v", s.Type())
+		}
+		return c.write(u, namespace)
+	}
+
+	return nil
+}
+
+// namespace returns the namespace of a named type, which is inherited from the
+// enclosing type if it is not set.
+func (c *canonicalizer) namespace(namespace, enclosing string) string {
+	if namespace != "" {
+		return namespace
+	}
+	return enclosing
+}
+
+// reference writes the full name if the named type has already been written,
+// otherwise it marks it as defined.
+func (c *canonicalizer) reference(fullname string) bool {
+	if c.defined[fullname] {
+		c.writeString(fullname)
+		return true
+	}
+	c.defined[fullname] = true
+	return false
+}
+
+func (c *canonicalizer) writeString(s string) {
+	b, _ := json.Marshal(s)
+	c.buf.Write(b)
+}
+
+// rabinEmpty is the fingerprint of the empty input, CRC-64-AVRO.
+const rabinEmpty = 0xc15d213aa4d7a795
+
+var rabinTable = func() (t [256]uint64) {
+	for i := range t {
+		fp := uint64(i)
+		for j := 0; j < 8; j++ {
+			fp = (fp >> 1) ^ (rabinEmpty & -(fp & 1))
+		}
+		t[i] = fp
+	}
+	return t
+}()
+
+// rabin returns the 64-bit Rabin fingerprint of the bytes.
+func rabin(b []byte) uint64 {
+	fp := uint64(rabinEmpty)
+	for _, c := range b {
+		fp = (fp >> 8) ^ rabinTable[byte(fp)^c]
+	}
+	return fp
+}
@@ -0,0 +1,217 @@
+package avro
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCanonicalForm(t *testing.T) {
+	tests := []struct {
+		Schema      string
+		Canonical   string
+		Fingerprint int64
+	}{
+		// Fingerprints are from the spec's test suite.
+		{`"null"`, `"null"`, 7195948357588979594},
+		{`{"type": "boolean"}`, `"boolean"`, -6970731678124411036},
+		{`"int"`, `"int"`, 8247732601305521295},
+		{`{"type": "long", "x-prop": 1}`, `"long"`, -3434872931120570953},
+		{`"string"`, `"string"`, -8142146995180207161},
+		{`["int", "boolean"]`, `["int","boolean"]`, 5392556393470105090},
+		{`{"type": "fixed", "name": "foo", "size": 15}`, `{"name":"foo","type":"fixed","size":15}`, 1756455273707447556},
+		{`{"type": "record", "name": "foo", "fields": []}`, `{"name":"foo","type":"record","fields":[]}`, -4824392279771201922},
+		{
+			Schema: `{
+				"type": "record",
+				"name": "Node",
+				"namespace": "com.example",
+				"doc": "A tree node.",
+				"aliases": ["Vertex"],
+				"fields": [
+					{"name": "id", "type": {"type": "int"}, "default": 0, "order": "descending"},
+					{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B"], "default": "A"}},
+					{"name": "when", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+					{"name": "children", "type": {"type": "array", "items": "Node"}},
+					{"name": "other", "type": ["null", "com.example.Kind"]}
+				]
+			}`,
+			Canonical: `{"name":"com.example.Node","type":"record","fields":[` +
+				`{"name":"id","type":"int"},` +
+				`{"name":"kind","type":{"name":"com.example.Kind","type":"enum","symbols":["A","B"]}},` +
+				`{"name":"when","type":"long"},` +
+				`{"name":"children","type":{"type":"array","items":"com.example.Node"}},` +
+				`{"name":"other","type":["null","com.example.Kind"]}]}`,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			s, err := Unmarshal([]byte(test.Schema))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := CanonicalForm(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != test.Canonical {
+				t.Errorf("expected %s, got %s", test.Canonical, b)
+			}
+
+			if test.Fingerprint == 0 {
+				return
+			}
+			fp, err := Fingerprint(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if int64(fp) != test.Fingerprint {
+				t.Errorf("expected fingerprint %d, got %d", test.Fingerprint, int64(fp))
+			}
+		})
+	}
+}
+
+func TestFingerprintVerbosePrimitive(t *testing.T) {
+	pairs := [][2]string{
+		{`"int"`, `{"type": "int"}`},
+		{
+			`{"type": "map", "values": "string"}`,
+			`{"type": "map", "values": {"type": "string"}}`,
+		},
+		{
+			`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "long"}]}`,
+			`{"type": "record", "name": "R", "fields": [{"name": "a", "type": {"type": "long"}}]}`,
+		},
+	}
+
+	for i, pair := range pairs {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			var fps [2]uint64
+			for j, b := range pair {
+				s, err := Unmarshal([]byte(b))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if fps[j], err = Fingerprint(s); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if fps[0] != fps[1] {
+				t.Errorf("expected equal fingerprints, got %x and %x", fps[0], fps[1])
+			}
+		})
+	}
+}
+
+func TestFingerprintEqual(t *testing.T) {
+	tests := []struct {
+		A, B  string
+		Equal bool
+	}{
+		{
+			`{"type": "record", "name": "R", "namespace": "x", "fields": [{"name": "a", "type": "int"}]}`,
+			`{"type": "record", "name": "x.R", "doc": "A record.", "aliases": ["S"], "fields": [{"name": "a", "type": "int", "default": 1}]}`,
+			true,
+		},
+		{
+			`{"type": "bytes", "logicalType": "decimal", "precision": 4, "scale": 2}`,
+			`"bytes"`,
+			true,
+		},
+		{
+			`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}, {"name": "b", "type": "int"}]}`,
+			`{"type": "record", "name": "R", "fields": [{"name": "b", "type": "int"}, {"name": "a", "type": "int"}]}`,
+			false,
+		},
+		{
+			`{"type": "enum", "name": "E", "symbols": ["A", "B"]}`,
+			`{"type": "enum", "name": "E", "symbols": ["A", "B", "C"]}`,
+			false,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			a, err := Unmarshal([]byte(test.A))
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := Unmarshal([]byte(test.B))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			eq, err := FingerprintEqual(a, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if eq != test.Equal {
+				t.Errorf("expected %v, got %v", test.Equal, eq)
+			}
+		})
+	}
+}
+
+func TestCanonicalFormKeepsOrder(t *testing.T) {
+	pairs := [][2]string{
+		{`["null", "string"]`, `["string", "null"]`},
+		{
+			`{"type": "record", "name": "R", "fields": [{"name": "a", "type": ["int", "long"]}]}`,
+			`{"type": "record", "name": "R", "fields": [{"name": "a", "type": ["long", "int"]}]}`,
+		},
+		{
+			`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}, {"name": "b", "type": "int"}]}`,
+			`{"type": "record", "name": "R", "fields": [{"name": "b", "type": "int"}, {"name": "a", "type": "int"}]}`,
+		},
+		{
+			`{"type": "enum", "name": "E", "symbols": ["A", "B"]}`,
+			`{"type": "enum", "name": "E", "symbols": ["B", "A"]}`,
+		},
+	}
+
+	for i, pair := range pairs {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			var fps [2]uint64
+			for j, b := range pair {
+				s, err := Unmarshal([]byte(b))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if fps[j], err = Fingerprint(s); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if fps[0] == fps[1] {
+				t.Errorf("expected different fingerprints, got %x for both", fps[0])
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	a := MustParse(`{"type": "record", "name": "R", "namespace": "x", "doc": "A record.", "fields": [{"name": "a", "type": "int"}]}`)
+	b := MustParse(`{"type": "record", "name": "x.R", "fields": [{"name": "a", "type": "int", "default": 0}]}`)
+	c := MustParse(`{"type": "record", "name": "x.R", "fields": [{"name": "a", "type": "long"}]}`)
+
+	keys := make(map[string]Schema)
+	for _, s := range []Schema{a, b, c} {
+		k, err := Key(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := keys[k]; !ok {
+			keys[k] = s
+		}
+	}
+
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(keys))
+	}
+	if k, _ := Key(b); keys[k] != a {
+		t.Errorf("expected %v to share the key of %v", b, a)
+	}
+}
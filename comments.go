@@ -0,0 +1,70 @@
+package avro
+
+// stripComments removes // line comments and trailing commas before a closing
+// bracket or brace from JSON text, leaving string contents untouched.
+func stripComments(b []byte) []byte {
+	// Remove the comments first, so that a comma followed by a comment is
+	// recognized as trailing.
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		switch {
+		case b[i] == '"':
+			j := stringEnd(b, i)
+			out = append(out, b[i:j]...)
+			i = j - 1
+
+		case b[i] == '/' && i+1 < len(b) && b[i+1] == '/':
+			for i < len(b) && b[i] != '\n' {
+				i++
+			}
+			if i < len(b) {
+				out = append(out, '\n')
+			}
+
+		default:
+			out = append(out, b[i])
+		}
+	}
+
+	b, out = out, make([]byte, 0, len(out))
+	for i := 0; i < len(b); i++ {
+		switch b[i] {
+		case '"':
+			j := stringEnd(b, i)
+			out = append(out, b[i:j]...)
+			i = j - 1
+
+		case ',':
+			j := i + 1
+			for j < len(b) && isSpace(b[j]) {
+				j++
+			}
+			if j < len(b) && (b[j] == ']' || b[j] == '}') {
+				continue
+			}
+			out = append(out, ',')
+
+		default:
+			out = append(out, b[i])
+		}
+	}
+	return out
+}
+
+// stringEnd returns the index just past the JSON string starting at i, or the
+// length of b if the string is not terminated.
+func stringEnd(b []byte, i int) int {
+	for j := i + 1; j < len(b); j++ {
+		switch b[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		}
+	}
+	return len(b)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
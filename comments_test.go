@@ -0,0 +1,53 @@
+package avro
+
+import "testing"
+
+func TestAllowComments(t *testing.T) {
+	in := `// A user of the service.
+{
+	"type": "record",
+	"name": "User",
+	"doc": "See https://example.com/users, [v2]",
+	"fields": [
+		{"name": "id", "type": "long"}, // Assigned by the database.
+		{"name": "tags", "type": {"type": "array", "items": "string",},},
+	],
+}
+`
+	exp := &Record{
+		Name: "User",
+		Doc:  "See https://example.com/users, [v2]",
+		Fields: []*Field{
+			{Name: "id", Type: Long},
+			{Name: "tags", Type: &Array{Items: String}},
+		},
+	}
+
+	p := Parser{AllowComments: true}
+	s, err := p.Parse([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Equal(s, exp) || s.(*Record).Doc != exp.Doc {
+		t.Errorf("expected %v, got %v", exp, s)
+	}
+
+	// Comments are not allowed by default.
+	_, err = Unmarshal([]byte(in))
+	checkError(t, err, "invalid character '/'")
+
+	// A file holding only comments is empty.
+	s, err = p.Parse([]byte("// Nothing yet.\n"))
+	if err != nil || s != nil {
+		t.Errorf("expected no schema, got %v, %v", s, err)
+	}
+
+	paths := writeFiles(t, [2]string{"user.avsc", in})
+	schemas, err := p.ParseFiles(paths...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := schemas[paths[0]]; !Equal(s, exp) {
+		t.Errorf("expected %v, got %v", exp, s)
+	}
+}
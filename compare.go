@@ -0,0 +1,176 @@
+package avro
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Compare orders two values of the schema by the Avro sort order, returning a
+// negative number if a sorts before b, zero if they are equal and a positive
+// number if a sorts after b. Both values must conform to the schema.
+//
+// Numbers compare by value, booleans with false first, and bytes, fixed and
+// strings lexicographically by byte. Enums compare by the position of their
+// symbols, arrays element by element with a shorter prefix first, and unions
+// by branch index and then by value. Records compare field by field in the
+// order they are defined: a field ordered "descending" reverses its result and
+// one ordered "ignore" is skipped. Maps have no order, and comparing them is an
+// error unless they are in an ignored field. Logical types compare as the type
+// they annotate, with decimals given as big numbers compared by their encoding.
+// https://avro.apache.org/docs/current/spec.html#order
+func Compare(s Schema, a, b interface{}) (int, error) {
+	if err := Conforms(s, a); err != nil {
+		return 0, err
+	}
+	if err := Conforms(s, b); err != nil {
+		return 0, err
+	}
+	return compareValues(s, a, b)
+}
+
+// compareValues compares two values known to conform to the schema.
+func compareValues(s Schema, a, b interface{}) (int, error) {
+	switch x := s.(type) {
+	case Primitive:
+		return comparePrimitives(x, a, b), nil
+
+	case *Record:
+		am, bm := a.(map[string]interface{}), b.(map[string]interface{})
+		for _, f := range x.Fields {
+			if f.Order == "ignore" {
+				continue
+			}
+			c, err := compareValues(f.Type, am[f.Name], bm[f.Name])
+			if err != nil {
+				return 0, withPath(f.Name, err)
+			}
+			if f.Order == "descending" {
+				c = -c
+			}
+			if c != 0 {
+				return c, nil
+			}
+		}
+		return 0, nil
+
+	case *Enum:
+		i, _ := x.Index(a.(string))
+		j, _ := x.Index(b.(string))
+		return compareInts(int64(i), int64(j)), nil
+
+	case *Array:
+		an, bn := sliceLen(a), sliceLen(b)
+		for i := 0; i < an && i < bn; i++ {
+			c, err := compareValues(x.Items, reflect.ValueOf(a).Index(i).Interface(), reflect.ValueOf(b).Index(i).Interface())
+			if err != nil {
+				return 0, withPath(fmt.Sprintf("[%d]", i), err)
+			}
+			if c != 0 {
+				return c, nil
+			}
+		}
+		return compareInts(int64(an), int64(bn)), nil
+
+	case *Map:
+		return 0, fmt.Errorf("avroschema: map values cannot be compared")
+
+	case Union:
+		i, as, _ := resolveBranch(x, a)
+		j, _, _ := resolveBranch(x, b)
+		if i != j {
+			return compareInts(int64(i), int64(j)), nil
+		}
+		return compareValues(as, unionValue(a), unionValue(b))
+
+	case *Fixed:
+		return bytes.Compare(a.([]byte), b.([]byte)), nil
+
+	case *Reference:
+		return compareValues(x.Schema, a, b)
+
+	case *Decimal, *annotatedDecimal, *FixedDecimal:
+		// Decimals compare by their encoded bytes like other logical types,
+		// whether they are given as bytes or big numbers.
+		ab, err := decimalBytes(x, a)
+		if err != nil {
+			return 0, err
+		}
+		bb, err := decimalBytes(x, b)
+		if err != nil {
+			return 0, err
+		}
+		return bytes.Compare(ab, bb), nil
+	}
+
+	// Logical types compare by their underlying value.
+	if u := Underlying(s); u != s {
+		return compareValues(u, a, b)
+	}
+
+	return 0, fmt.Errorf("avroschema: unsupported schema type %v", s.Type())
+}
+
+func comparePrimitives(p Primitive, a, b interface{}) int {
+	switch p {
+	case Boolean:
+		x, y := a.(bool), b.(bool)
+		switch {
+		case x == y:
+			return 0
+		case x:
+			return 1
+		}
+		return -1
+
+	case Int, Long:
+		x, _ := toInt64(a)
+		y, _ := toInt64(b)
+		return compareInts(x, y)
+
+	case Float, Double:
+		x, y := toFloat64(a), toFloat64(b)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+
+	case Bytes:
+		return bytes.Compare(a.([]byte), b.([]byte))
+
+	case String:
+		return strings.Compare(a.(string), b.(string))
+	}
+
+	// Nulls are always equal.
+	return 0
+}
+
+// sliceLen returns the length of an array value, which is zero for nil.
+func sliceLen(v interface{}) int {
+	if v == nil {
+		return 0
+	}
+	return reflect.ValueOf(v).Len()
+}
+
+func compareInts(x, y int64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func toFloat64(v interface{}) float64 {
+	if f, ok := v.(float32); ok {
+		return float64(f)
+	}
+	return v.(float64)
+}
@@ -0,0 +1,119 @@
+package avro
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	suit := &Enum{Name: "Suit", Symbols: []string{"SPADES", "HEARTS", "DIAMONDS", "CLUBS"}}
+
+	// Orders are sorted by customer, then by descending total, ignoring the
+	// notes.
+	order := &Record{
+		Name: "Order",
+		Fields: []*Field{
+			{Name: "customer", Type: String},
+			{Name: "total", Type: Double, Order: "descending"},
+			{Name: "notes", Type: &Map{Values: String}, Order: "ignore"},
+		},
+	}
+	newOrder := func(customer string, total float64) map[string]interface{} {
+		return map[string]interface{}{
+			"customer": customer,
+			"total":    total,
+			"notes":    map[string]interface{}{},
+		}
+	}
+
+	tests := []struct {
+		Schema Schema
+		A, B   interface{}
+		Result int
+	}{
+		{Null, nil, nil, 0},
+		{Boolean, false, true, -1},
+		{Boolean, true, true, 0},
+		{Int, int32(-5), int32(3), -1},
+		{Long, int64(math.MaxInt64), int64(0), 1},
+		{Float, float32(1.5), float32(1.5), 0},
+		{Double, -0.5, 0.25, -1},
+		{Bytes, []byte{0x01, 0xff}, []byte{0x02}, -1},
+		{Bytes, []byte{0x80}, []byte{0x7f}, 1},
+		{String, "apple", "apples", -1},
+		{String, "Zebra", "apple", -1},
+		{&Fixed{Name: "F", Size: 2}, []byte{0, 1}, []byte{0, 1}, 0},
+		{Date, int32(17000), int32(16000), 1},
+
+		// Decimals compare by their encoded bytes, given as bytes or numbers,
+		// so 2.00, encoded as 00c8, sorts before 1.00, encoded as 64.
+		{&Decimal{Precision: 9, Scale: 2}, big.NewRat(1, 2), []byte{0x31}, 1},
+		{&Decimal{Precision: 9, Scale: 2}, big.NewRat(1, 2), big.NewRat(50, 100), 0},
+		{&Decimal{Precision: 9, Scale: 2}, big.NewInt(2), big.NewInt(1), -1},
+		{&FixedDecimal{Precision: 9, Scale: 2, Fixed: &Fixed{Name: "F", Size: 4}}, big.NewInt(1), big.NewInt(2), -1},
+
+		// Enums compare by symbol position, not name.
+		{suit, "SPADES", "CLUBS", -1},
+		{suit, "HEARTS", "DIAMONDS", -1},
+
+		// Arrays compare element-wise, then by length.
+		{&Array{Items: Int}, []interface{}{int32(1), int32(2)}, []interface{}{int32(1), int32(3)}, -1},
+		{&Array{Items: Int}, []interface{}{int32(1), int32(2)}, []interface{}{int32(1)}, 1},
+		{&Array{Items: Int}, []interface{}{}, []interface{}{}, 0},
+		{&Array{Items: Int}, nil, []interface{}{}, 0},
+		{&Array{Items: Int}, nil, []interface{}{int32(0)}, -1},
+
+		// Unions compare by branch index before value.
+		{Union{Null, String}, nil, "a", -1},
+		{Union{String, Long}, int64(1), "z", 1},
+		{Union{Null, Long}, int64(2), int64(10), -1},
+		{Union{Int, Long}, UnionValue{Index: 1, Value: int64(0)}, UnionValue{Index: 0, Value: int32(5)}, 1},
+
+		// Records compare field by field honoring the sort order.
+		{order, newOrder("alice", 10), newOrder("bob", 99), -1},
+		{order, newOrder("alice", 10), newOrder("alice", 99), 1},
+		{order, newOrder("alice", 10), newOrder("alice", 10), 0},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			c, err := Compare(test.Schema, test.A, test.B)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c != test.Result {
+				t.Errorf("expected %d, got %d", test.Result, c)
+			}
+
+			// Swapping the values reverses the result.
+			c, err = Compare(test.Schema, test.B, test.A)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c != -test.Result {
+				t.Errorf("expected %d swapped, got %d", -test.Result, c)
+			}
+		})
+	}
+}
+
+func TestCompareErrors(t *testing.T) {
+	r := &Record{
+		Name: "R",
+		Fields: []*Field{
+			{Name: "tags", Type: &Map{Values: String}},
+		},
+	}
+	v := map[string]interface{}{"tags": map[string]interface{}{}}
+
+	_, err := Compare(r, v, v)
+	checkError(t, err, "tags: map values cannot be compared")
+
+	_, err = Compare(Int, int32(1), "one")
+	checkError(t, err, "cannot represent int")
+
+	_, err = Compare(&Decimal{Precision: 4, Scale: 2}, big.NewInt(1), (*big.Int)(nil))
+	checkError(t, err, "nil value cannot represent decimal")
+}
@@ -0,0 +1,288 @@
+package avro
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Level is a schema registry compatibility level, which decides the schemas a
+// new version must be compatible with and in which direction.
+type Level int
+
+const (
+	// Backward requires the new schema to read data written with the latest
+	// existing version, so consumers can upgrade first.
+	Backward Level = iota
+	// BackwardTransitive requires the new schema to read data written with
+	// every existing version.
+	BackwardTransitive
+	// Forward requires the latest existing version to read data written with
+	// the new schema, so producers can upgrade first.
+	Forward
+	// ForwardTransitive requires every existing version to read data written
+	// with the new schema.
+	ForwardTransitive
+	// Full is both Backward and Forward.
+	Full
+	// FullTransitive is both BackwardTransitive and ForwardTransitive.
+	FullTransitive
+)
+
+var levelNames = [...]string{
+	Backward:           "BACKWARD",
+	BackwardTransitive: "BACKWARD_TRANSITIVE",
+	Forward:            "FORWARD",
+	ForwardTransitive:  "FORWARD_TRANSITIVE",
+	Full:               "FULL",
+	FullTransitive:     "FULL_TRANSITIVE",
+}
+
+// String returns the name of the level as schema registries spell it.
+func (l Level) String() string {
+	if l < 0 || int(l) >= len(levelNames) {
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// Incompatibility is a reason data written with one schema cannot be read with
+// another. Path locates it as in Change, and is empty for the top-level schema.
+type Incompatibility struct {
+	// Version is the index in existing of the version the new schema is
+	// incompatible with. It is zero for Compatible.
+	Version int
+
+	// Forward is set if the existing version cannot read the new schema,
+	// rather than the new schema the existing version.
+	Forward bool
+
+	Path    string
+	Message string
+}
+
+func (i Incompatibility) String() string {
+	path := i.Path
+	if path == "" {
+		path = "(root)"
+	}
+	return fmt.Sprintf("%v: %v", path, i.Message)
+}
+
+// CheckCompatibility checks the new schema against the existing versions,
+// ordered from oldest to newest, at the level, as a schema registry does
+// before registering a new version. Non-transitive levels check the latest
+// version only. It returns every incompatibility found, which is none if the
+// new schema may be registered.
+func CheckCompatibility(level Level, newSchema Schema, existing []Schema) ([]Incompatibility, error) {
+	var backward, forward, transitive bool
+	switch level {
+	case Backward, BackwardTransitive:
+		backward = true
+	case Forward, ForwardTransitive:
+		forward = true
+	case Full, FullTransitive:
+		backward, forward = true, true
+	default:
+		return nil, fmt.Errorf("avroschema: unknown compatibility level %v", level)
+	}
+	switch level {
+	case BackwardTransitive, ForwardTransitive, FullTransitive:
+		transitive = true
+	}
+
+	first := 0
+	if !transitive && len(existing) > 0 {
+		first = len(existing) - 1
+	}
+
+	var all []Incompatibility
+	for v := first; v < len(existing); v++ {
+		if backward {
+			incs, err := Compatible(newSchema, existing[v])
+			if err != nil {
+				return nil, err
+			}
+			for _, i := range incs {
+				i.Version = v
+				all = append(all, i)
+			}
+		}
+		if forward {
+			incs, err := Compatible(existing[v], newSchema)
+			if err != nil {
+				return nil, err
+			}
+			for _, i := range incs {
+				i.Version = v
+				i.Forward = true
+				all = append(all, i)
+			}
+		}
+	}
+	return all, nil
+}
+
+// Compatible checks that data written with the writer schema can be read with
+// the reader schema by the spec's schema resolution rules, returning every
+// incompatibility found:
+//
+// Named types must have the same unqualified name, or the reader's aliases
+// must include the writer's full name. Reader fields missing from the writer
+// need a default, and writer fields missing from the reader are skipped. Enum
+// symbols of the writer must be in the reader unless the reader has a default,
+// and fixed sizes must be equal. Ints, longs and floats are promoted to the
+// wider numeric types, and strings and bytes read each other. Each branch of a
+// writer union must be readable, and a reader union must have a branch that
+// reads the writer. Logical types are resolved as the type they annotate.
+//
+// The schemas are walked as Diff walks them, so incompatibilities have the
+// paths of the changes behind them, and a pair of named types reached again is
+// not checked twice.
+// https://avro.apache.org/docs/current/spec.html#Schema+Resolution
+func Compatible(reader, writer Schema) ([]Incompatibility, error) {
+	c := &compatChecker{}
+	w := newWalker(c)
+	w.underlying = true
+	if err := w.walk(reader, writer, ""); err != nil {
+		return nil, err
+	}
+	return c.incs, nil
+}
+
+// promotions lists the reader types each writer primitive can be read as,
+// besides itself.
+var promotions = map[Primitive][]Primitive{
+	Int:    {Long, Float, Double},
+	Long:   {Float, Double},
+	Float:  {Double},
+	String: {Bytes},
+	Bytes:  {String},
+}
+
+// compatChecker checks a reader schema, walked as a, against a writer schema,
+// walked as b. Pairs of named types reached again, such as through a cycle,
+// are assumed compatible.
+type compatChecker struct {
+	incs []Incompatibility
+}
+
+func (c *compatChecker) add(path, format string, args ...interface{}) {
+	c.incs = append(c.incs, Incompatibility{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// names reports whether the reader's named type matches the writer's, adding
+// an incompatibility if it does not.
+func (c *compatChecker) names(reader, writer Named, path string) bool {
+	if shortName(reader.Fullname()) == shortName(writer.Fullname()) || HasName(reader, writer.Fullname()) {
+		return true
+	}
+	c.add(path, "reader %v %v does not match writer name %v", reader.Type(), reader.Fullname(), writer.Fullname())
+	return false
+}
+
+func (c *compatChecker) field(writer *Record, rf *Field) *Field {
+	return writerField(writer, rf)
+}
+
+// onlyA checks a reader field missing from the writer has a default.
+func (c *compatChecker) onlyA(rf *Field, path string) {
+	if !rf.HasDefault() {
+		c.add(path, "reader field %v has no default and is missing from the writer", rf.Name)
+	}
+}
+
+// onlyB skips a writer field missing from the reader.
+func (c *compatChecker) onlyB(wf *Field, path string) {}
+
+func (c *compatChecker) symbols(reader, writer *Enum, path string) {
+	if reader.Default != "" {
+		return
+	}
+	for _, s := range writer.Symbols {
+		if _, ok := reader.Index(s); !ok {
+			c.add(path, "writer symbol %v is missing from reader enum %v, which has no default", s, typeString(reader))
+		}
+	}
+}
+
+func (c *compatChecker) size(reader, writer *Fixed, path string) {
+	if reader.Size != writer.Size {
+		c.add(path, "reader fixed %v of size %d cannot read size %d", typeString(reader), reader.Size, writer.Size)
+	}
+}
+
+func (c *compatChecker) union(w *walker, reader, writer Schema, path string) error {
+	// Every branch of a writer union must be readable.
+	if wu, ok := writer.(Union); ok {
+		for _, s := range wu {
+			if err := w.walk(reader, s, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// A reader union must have a branch that reads the writer. A branch that
+	// fails must not leave its named types marked as seen.
+	ru := reader.(Union)
+	for _, r := range ru {
+		sub := &compatChecker{}
+		if err := w.fork(sub).walk(r, writer, path); err != nil {
+			return err
+		}
+		if len(sub.incs) == 0 {
+			return nil
+		}
+	}
+	c.add(path, "no branch of reader union %v can read writer type %v", unionTypes(ru), typeString(writer))
+	return nil
+}
+
+func (c *compatChecker) other(reader, writer Schema, path string) error {
+	for _, s := range []Schema{reader, writer} {
+		if r, ok := s.(*Reference); ok {
+			return fmt.Errorf("avroschema: reference to %v is not resolved", r.Name)
+		}
+	}
+
+	switch w := writer.(type) {
+	case Primitive:
+		if r, ok := reader.(Primitive); ok && (r == w || promotes(w, r)) {
+			return nil
+		}
+	case *Record, *Enum, *Fixed, *Array, *Map:
+		// The reader is of another kind.
+	default:
+		return fmt.Errorf("avroschema: unsupported schema type %v", writer.Type())
+	}
+
+	c.add(path, "reader type %v cannot read writer type %v", typeString(reader), typeString(writer))
+	return nil
+}
+
+// writerField returns the writer field the reader field reads, matched by name
+// or by one of the reader field's aliases.
+func writerField(w *Record, rf *Field) *Field {
+	if f, ok := w.Field(rf.Name); ok {
+		return f
+	}
+	for _, a := range rf.Aliases {
+		if f, ok := w.Field(a); ok {
+			return f
+		}
+	}
+	return nil
+}
+
+func promotes(writer, reader Primitive) bool {
+	for _, p := range promotions[writer] {
+		if p == reader {
+			return true
+		}
+	}
+	return false
+}
+
+func shortName(fullname string) string {
+	return fullname[strings.LastIndex(fullname, ".")+1:]
+}
@@ -0,0 +1,169 @@
+package avro
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestCompatible(t *testing.T) {
+	v1 := `{"type": "record", "name": "User", "namespace": "com.example", "fields": [
+		{"name": "id", "type": "int"},
+		{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["ACTIVE", "BANNED"]}}
+	]}`
+
+	tests := []struct {
+		Reader, Writer string
+		Incs           []string
+	}{
+		// Promotions.
+		{`"long"`, `"int"`, nil},
+		{`"double"`, `"float"`, nil},
+		{`"bytes"`, `"string"`, nil},
+		{`"int"`, `"long"`, []string{"(root): reader type int cannot read writer type long"}},
+		{`{"type": "int", "logicalType": "date"}`, `"int"`, nil},
+
+		// Unions.
+		{`["null", "long"]`, `"int"`, nil},
+		{`"long"`, `["int", "long"]`, nil},
+		{`"long"`, `["null", "long"]`, []string{"(root): reader type long cannot read writer type null"}},
+		{`["null", "string"]`, `"int"`, []string{"(root): no branch of reader union [null string] can read writer type int"}},
+
+		// Records.
+		{v1, v1, nil},
+		{
+			`{"type": "record", "name": "User", "namespace": "com.example", "fields": [
+				{"name": "id", "type": "long"},
+				{"name": "email", "type": ["null", "string"], "default": null}
+			]}`,
+			v1,
+			nil,
+		},
+		{
+			`{"type": "record", "name": "User", "namespace": "com.example", "fields": [
+				{"name": "id", "type": "int"},
+				{"name": "email", "type": "string"}
+			]}`,
+			v1,
+			[]string{"email: reader field email has no default and is missing from the writer"},
+		},
+		{
+			`{"type": "record", "name": "User", "namespace": "com.example", "fields": [
+				{"name": "userId", "type": "int", "aliases": ["id"]}
+			]}`,
+			v1,
+			nil,
+		},
+		{`{"type": "record", "name": "other.User", "fields": []}`, v1, nil},
+		{`{"type": "record", "name": "Person", "aliases": ["com.example.User"], "fields": []}`, v1, nil},
+		{
+			`{"type": "record", "name": "Person", "fields": []}`,
+			v1,
+			[]string{"(root): reader record Person does not match writer name com.example.User"},
+		},
+
+		// Enums and fixed types.
+		{
+			`{"type": "record", "name": "User", "namespace": "com.example", "fields": [
+				{"name": "id", "type": "int"},
+				{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["ACTIVE"]}}
+			]}`,
+			v1,
+			[]string{"status: writer symbol BANNED is missing from reader enum com.example.Status, which has no default"},
+		},
+		{
+			`{"type": "enum", "name": "Status", "symbols": ["ACTIVE", "UNKNOWN"], "default": "UNKNOWN"}`,
+			`{"type": "enum", "name": "Status", "symbols": ["ACTIVE", "BANNED"]}`,
+			nil,
+		},
+		{
+			`{"type": "map", "values": {"type": "fixed", "name": "F", "size": 4}}`,
+			`{"type": "map", "values": {"type": "fixed", "name": "F", "size": 8}}`,
+			[]string{"[]: reader fixed F(4) of size 4 cannot read size 8"},
+		},
+
+		// Paths are those of Diff.
+		{
+			`{"type": "array", "items": {"type": "record", "name": "Line", "fields": [{"name": "qty", "type": "int"}]}}`,
+			`{"type": "array", "items": {"type": "record", "name": "Line", "fields": [{"name": "qty", "type": "long"}]}}`,
+			[]string{"[].qty: reader type int cannot read writer type long"},
+		},
+
+		// Recursive records terminate.
+		{
+			`{"type": "record", "name": "Node", "fields": [{"name": "next", "type": ["null", "Node"]}]}`,
+			`{"type": "record", "name": "Node", "fields": [{"name": "next", "type": ["null", "Node"]}]}`,
+			nil,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			incs, err := Compatible(MustParse(test.Reader), MustParse(test.Writer))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, inc := range incs {
+				got = append(got, inc.String())
+			}
+			if !reflect.DeepEqual(got, test.Incs) {
+				t.Errorf("expected %q, got %q", test.Incs, got)
+			}
+		})
+	}
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	// v2 adds the field b with a default and v3 the field c without one.
+	v1 := MustParse(`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}]}`)
+	v2 := MustParse(`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}, {"name": "b", "type": "int", "default": 0}]}`)
+	v3 := MustParse(`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}, {"name": "b", "type": "int", "default": 0}, {"name": "c", "type": "int"}]}`)
+
+	// The new version drops b, which only v1 and v2 can read without c.
+	next := MustParse(`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}, {"name": "c", "type": "int"}]}`)
+	missingC := "reader field c has no default and is missing from the writer"
+
+	tests := []struct {
+		Level    Level
+		New      Schema
+		Existing []Schema
+		Incs     []Incompatibility
+	}{
+		{Backward, next, nil, nil},
+		{Backward, next, []Schema{v1, v2, v3}, nil},
+		{BackwardTransitive, next, []Schema{v1, v2, v3}, []Incompatibility{
+			{Version: 0, Path: "c", Message: missingC},
+			{Version: 1, Path: "c", Message: missingC},
+		}},
+		{Forward, next, []Schema{v1, v2, v3}, nil},
+		{ForwardTransitive, next, []Schema{v1, v2, v3}, nil},
+		{Full, next, []Schema{v1, v2, v3}, nil},
+		{FullTransitive, next, []Schema{v1, v2, v3}, []Incompatibility{
+			{Version: 0, Path: "c", Message: missingC},
+			{Version: 1, Path: "c", Message: missingC},
+		}},
+
+		// Going back to v1 is backward but not forward compatible with v3.
+		{Backward, v1, []Schema{v3}, nil},
+		{Forward, v1, []Schema{v3}, []Incompatibility{
+			{Version: 0, Forward: true, Path: "c", Message: missingC},
+		}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d %v", i, test.Level), func(t *testing.T) {
+			incs, err := CheckCompatibility(test.Level, test.New, test.Existing)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(incs, test.Incs) {
+				t.Errorf("expected %v, got %v", test.Incs, incs)
+			}
+		})
+	}
+
+	_, err := CheckCompatibility(Level(10), next, []Schema{v1})
+	checkError(t, err, "unknown compatibility level Level(10)")
+}
@@ -0,0 +1,250 @@
+package avro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultValue returns the field's default as the Go value that represents it,
+// for example an int64 for a long default which is decoded from JSON as a
+// json.Number. The default of a union field is decoded as its first branch and
+// returned as a UnionValue tagged with that branch. An error is returned if the
+// default does not conform to the field's type or the field has no default.
+func DefaultValue(f *Field) (interface{}, error) {
+	if !f.HasDefault() {
+		return nil, fmt.Errorf("avroschema: field %v has no default", f.Name)
+	}
+
+	v, err := defaultValue(f.Type, f.Default)
+	if err != nil {
+		return nil, fmt.Errorf("avroschema: invalid default for field %v: %s", f.Name, err)
+	}
+	return v, nil
+}
+
+// defaultValue converts a JSON-decoded default to the value representing the
+// schema.
+func defaultValue(s Schema, v interface{}) (interface{}, error) {
+	switch x := s.(type) {
+	case Primitive:
+		return defaultPrimitive(x, v)
+
+	case *Record:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, defaultError(s, v)
+		}
+
+		r := make(map[string]interface{}, len(x.Fields))
+		for _, f := range x.Fields {
+			var (
+				fv  interface{}
+				err error
+			)
+			if d, ok := m[f.Name]; ok {
+				fv, err = defaultValue(f.Type, d)
+			} else if f.HasDefault() {
+				// Fall back to the field's own default.
+				fv, err = defaultValue(f.Type, f.Default)
+			} else {
+				err = fmt.Errorf("record %v default is missing field %v", x.Name, f.Name)
+			}
+			if err != nil {
+				return nil, err
+			}
+			r[f.Name] = fv
+		}
+		return r, nil
+
+	case *Enum:
+		sym, ok := v.(string)
+		if !ok {
+			return nil, defaultError(s, v)
+		}
+		if _, ok := x.Index(sym); !ok {
+			return nil, fmt.Errorf("%q is not a symbol of enum %v", sym, x.Name)
+		}
+		return sym, nil
+
+	case *Array:
+		a, ok := v.([]interface{})
+		if !ok {
+			return nil, defaultError(s, v)
+		}
+
+		r := make([]interface{}, len(a))
+		for i, e := range a {
+			ev, err := defaultValue(x.Items, e)
+			if err != nil {
+				return nil, err
+			}
+			r[i] = ev
+		}
+		return r, nil
+
+	case *Map:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, defaultError(s, v)
+		}
+
+		r := make(map[string]interface{}, len(m))
+		for k, e := range m {
+			ev, err := defaultValue(x.Values, e)
+			if err != nil {
+				return nil, err
+			}
+			r[k] = ev
+		}
+		return r, nil
+
+	case Union:
+		// The default of a union corresponds to its first branch.
+		if len(x) == 0 {
+			return nil, fmt.Errorf("union has no branches")
+		}
+		bv, err := defaultValue(x[0], v)
+		if err != nil {
+			return nil, err
+		}
+		return UnionValue{Index: 0, Value: bv}, nil
+
+	case *Fixed:
+		b, err := defaultBytes(v)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != x.Size {
+			return nil, fmt.Errorf("fixed %v requires %d bytes, got %d", x.Name, x.Size, len(b))
+		}
+		return b, nil
+
+	case *Reference:
+		if x.Schema == nil {
+			return nil, fmt.Errorf("reference to %v is not resolved", x.Name)
+		}
+		return defaultValue(x.Schema, v)
+	}
+
+	// Logical types are represented by their underlying type.
+	if u := Underlying(s); u != s {
+		return defaultValue(u, v)
+	}
+
+	return nil, fmt.Errorf("unsupported schema type %v", s.Type())
+}
+
+func defaultPrimitive(p Primitive, v interface{}) (interface{}, error) {
+	switch p {
+	case Null:
+		if v != nil {
+			return nil, defaultError(p, v)
+		}
+		return nil, nil
+
+	case Boolean:
+		if _, ok := v.(bool); !ok {
+			return nil, defaultError(p, v)
+		}
+		return v, nil
+
+	case Int:
+		n, ok := defaultInt(v)
+		if !ok || n < math.MinInt32 || n > math.MaxInt32 {
+			return nil, defaultError(p, v)
+		}
+		return int32(n), nil
+
+	case Long:
+		n, ok := defaultInt(v)
+		if !ok {
+			return nil, defaultError(p, v)
+		}
+		return n, nil
+
+	case Float:
+		f, ok := defaultFloat(v)
+		if !ok || math.IsNaN(f) || math.Abs(f) > math.MaxFloat32 {
+			return nil, defaultError(p, v)
+		}
+		return float32(f), nil
+
+	case Double:
+		f, ok := defaultFloat(v)
+		if !ok || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, defaultError(p, v)
+		}
+		return f, nil
+
+	case Bytes:
+		return defaultBytes(v)
+
+	case String:
+		if _, ok := v.(string); !ok {
+			return nil, defaultError(p, v)
+		}
+		return v, nil
+	}
+
+	return nil, fmt.Errorf("unsupported schema type %v", p)
+}
+
+// defaultInt converts a JSON number, or a Go integer in a schema built by hand,
+// to an int64. JSON numbers must be integral and within range. A json.Number
+// written as an integer is converted exactly.
+func defaultInt(v interface{}) (int64, bool) {
+	if n, ok := v.(json.Number); ok {
+		if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+			return i, true
+		}
+		v, _ = n.Float64()
+	}
+	if f, ok := v.(float64); ok {
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(f), true
+	}
+	return toInt64(v)
+}
+
+func defaultFloat(v interface{}) (float64, bool) {
+	switch f := v.(type) {
+	case json.Number:
+		n, err := f.Float64()
+		return n, err == nil
+	case float64:
+		return f, true
+	case float32:
+		return float64(f), true
+	}
+
+	if n, ok := toInt64(v); ok {
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// defaultBytes decodes a bytes or fixed default, which the spec encodes as a
+// string whose code points 0-255 map to byte values 0-255.
+func defaultBytes(v interface{}) ([]byte, error) {
+	switch x := v.(type) {
+	case []byte:
+		return x, nil
+	case string:
+		b, err := DecodeJSONBytes(x)
+		if err != nil {
+			return nil, errors.New(strings.TrimPrefix(err.Error(), "avroschema: "))
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("value of type %T cannot represent bytes", v)
+}
+
+func defaultError(s Schema, v interface{}) error {
+	return fmt.Errorf("value %v of type %T cannot represent %v", v, v, s.Type())
+}
@@ -0,0 +1,187 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDefaultValue(t *testing.T) {
+	point := &Record{
+		Name: "point",
+		Fields: []*Field{
+			{Name: "x", Type: Int},
+			{Name: "y", Type: Int, Default: float64(0)},
+		},
+	}
+
+	tests := []struct {
+		Type    Schema
+		Default string
+		Value   interface{}
+	}{
+		{Boolean, `true`, true},
+		{Int, `42`, int32(42)},
+		{Long, `-7`, int64(-7)},
+		{Float, `1.5`, float32(1.5)},
+		{Double, `2`, float64(2)},
+		{String, `"hi"`, "hi"},
+		{Bytes, `"\u0000\u007f\u0080ÿ"`, []byte{0x00, 0x7f, 0x80, 0xff}},
+		{&Fixed{Name: "f", Size: 2}, `"ab"`, []byte("ab")},
+		{&Enum{Name: "e", Symbols: []string{"A", "B"}}, `"B"`, "B"},
+		{&Array{Items: Long}, `[1, 2]`, []interface{}{int64(1), int64(2)}},
+		{&Map{Values: Double}, `{"a": 1}`, map[string]interface{}{"a": float64(1)}},
+		{point, `{"x": 1}`, map[string]interface{}{"x": int32(1), "y": int32(0)}},
+		{Date, `17000`, int32(17000)},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			var d interface{}
+			if err := json.Unmarshal([]byte(test.Default), &d); err != nil {
+				t.Fatal(err)
+			}
+
+			v, err := DefaultValue(&Field{Name: "f", Type: test.Type, Default: d})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.Value, v); diff != "" {
+				t.Errorf("(-want +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDefaultValueInvalid(t *testing.T) {
+	point := &Record{
+		Name: "point",
+		Fields: []*Field{
+			{Name: "x", Type: Int},
+		},
+	}
+
+	tests := []struct {
+		Type    Schema
+		Default interface{}
+	}{
+		{Int, 1.5},
+		{Int, float64(1 << 40)},
+		{Int, json.Number("3000000000")},
+		{Long, json.Number("9223372036854775808")},
+		{Float, json.Number("1e39")},
+		{Double, json.Number("1e400")},
+		{Double, math.Inf(1)},
+		{Long, "1"},
+		{String, nil},
+		{Bytes, "Ā"},
+		{&Fixed{Name: "f", Size: 2}, "abc"},
+		{&Enum{Name: "e", Symbols: []string{"A"}}, "B"},
+		{point, map[string]interface{}{}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			if _, err := DefaultValue(&Field{Name: "f", Type: test.Type, Default: test.Default}); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestDefaultValueUnion(t *testing.T) {
+	b := []byte(`{
+		"type": "record",
+		"name": "test",
+		"fields": [
+			{"name": "a", "type": ["null", "string"], "default": null},
+			{"name": "b", "type": ["string", "null"], "default": "hello"},
+			{"name": "c", "type": ["null", "string"]}
+		]
+	}`)
+
+	s, err := Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := s.(*Record)
+
+	v, err := DefaultValue(r.Fields[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != (UnionValue{Index: 0, Value: nil}) {
+		t.Errorf("expected null branch, got %v", v)
+	}
+
+	v, err = DefaultValue(r.Fields[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != (UnionValue{Index: 0, Value: "hello"}) {
+		t.Errorf("expected string branch, got %v", v)
+	}
+
+	// A field without a default is distinct from a null default.
+	if _, err := DefaultValue(r.Fields[2]); err == nil {
+		t.Errorf("expected error for field without default")
+	}
+
+	// The null default survives marshaling while the absent one stays absent.
+	b, err = Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := `{"type":"record","name":"test","fields":[` +
+		`{"name":"a","type":["null","string"],"default":null},` +
+		`{"name":"b","type":["string","null"],"default":"hello"},` +
+		`{"name":"c","type":["null","string"]}]}`
+	if string(b) != exp {
+		t.Errorf("expected %s, got %s", exp, b)
+	}
+}
+
+func TestDefaultValueLargeLong(t *testing.T) {
+	in := `{"type":"record","name":"R","fields":[` +
+		`{"name":"id","type":"long","default":9007199254740993},` +
+		`{"name":"min","type":"long","default":-9223372036854775808},` +
+		`{"name":"ratio","type":"double","default":0.1}]}`
+
+	s, err := Unmarshal([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := s.(*Record)
+
+	// Integers beyond 2^53 are kept exactly rather than rounded to a float64.
+	tests := []interface{}{int64(9007199254740993), int64(math.MinInt64), 0.1}
+	for i, exp := range tests {
+		v, err := DefaultValue(r.Fields[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != exp {
+			t.Errorf("field %v: expected %v, got %v", r.Fields[i].Name, exp, v)
+		}
+	}
+
+	b, err := Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != in {
+		t.Errorf("expected %s, got %s", in, b)
+	}
+
+	// Fractional and out of range numbers are still rejected.
+	for _, d := range []json.Number{"1.5", "9223372036854775808"} {
+		if _, err := DefaultValue(&Field{Name: "f", Type: Long, Default: d}); err == nil {
+			t.Errorf("expected error for %v", d)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package avro
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChangeKind is the kind of difference between two schemas.
+type ChangeKind int
+
+const (
+	// FieldAdded is a record field only in the new schema.
+	FieldAdded ChangeKind = iota
+	// FieldRemoved is a record field only in the old schema.
+	FieldRemoved
+	// TypeChanged is a type replaced by a different one, including changes
+	// to a fixed size or decimal precision and scale.
+	TypeChanged
+	// NameChanged is a named type whose name differs.
+	NameChanged
+	// NamespaceChanged is a named type whose namespace differs.
+	NamespaceChanged
+	// SymbolAdded is an enum symbol only in the new schema.
+	SymbolAdded
+	// SymbolRemoved is an enum symbol only in the old schema.
+	SymbolRemoved
+	// BranchAdded is a union branch only in the new schema.
+	BranchAdded
+	// BranchRemoved is a union branch only in the old schema.
+	BranchRemoved
+)
+
+// Change is a single difference between two schemas. Path locates it by field
+// names separated by dots, with [] for array items and map values, and is
+// empty for the top-level schema. Old and New hold the values before and after
+// the change: types, names or symbols depending on the kind. Old is empty for
+// additions and New for removals.
+type Change struct {
+	Kind ChangeKind
+	Path string
+	Old  string
+	New  string
+}
+
+// String renders the change as a line of a diff, prefixed with + for
+// additions, - for removals and ~ for modifications.
+func (c Change) String() string {
+	path := c.Path
+	if path == "" {
+		path = "(root)"
+	}
+
+	switch c.Kind {
+	case FieldAdded:
+		return fmt.Sprintf("+ field %v: %v", path, c.New)
+	case FieldRemoved:
+		return fmt.Sprintf("- field %v: %v", path, c.Old)
+	case TypeChanged:
+		return fmt.Sprintf("~ type %v: %v -> %v", path, c.Old, c.New)
+	case NameChanged:
+		return fmt.Sprintf("~ name %v: %v -> %v", path, c.Old, c.New)
+	case NamespaceChanged:
+		return fmt.Sprintf("~ namespace %v: %q -> %q", path, c.Old, c.New)
+	case SymbolAdded:
+		return fmt.Sprintf("+ symbol %v: %v", path, c.New)
+	case SymbolRemoved:
+		return fmt.Sprintf("- symbol %v: %v", path, c.Old)
+	case BranchAdded:
+		return fmt.Sprintf("+ branch %v: %v", path, c.New)
+	case BranchRemoved:
+		return fmt.Sprintf("- branch %v: %v", path, c.Old)
+	}
+
+	return fmt.Sprintf("? %v: %v -> %v", path, c.Old, c.New)
+}
+
+// Diff describes how schema b differs from schema a. Records are compared by
+// field name, enums by symbol and unions by branch type, so reordering alone
+// is not a change. Docs, aliases, defaults and custom attributes are ignored.
+// Unlike a compatibility check, Diff does not judge whether data written with
+// one schema can be read with the other.
+func Diff(a, b Schema) []Change {
+	d := &differ{}
+	newWalker(d).walk(a, b, "")
+	return d.changes
+}
+
+// walker walks two schemas side by side, pairing up their parts: the fields of
+// records, the items of arrays and the values of maps, and union branches as
+// the visitor decides. Diff and Compatible both walk schemas this way, so they
+// agree on how the parts of two schemas correspond.
+type walker struct {
+	v visitor
+
+	// underlying is set to walk logical types as the types they annotate.
+	underlying bool
+
+	// seen holds the pairs of named types already walked, by full name, so
+	// recursive schemas terminate.
+	seen map[[2]string]bool
+}
+
+// visitor is told about the pairs of schemas a walker reaches, which it
+// reports on. It is given the walker to walk the union branches it pairs up.
+type visitor interface {
+	// names compares two named types of the same kind, reporting whether
+	// their parts should be walked.
+	names(a, b Named, path string) bool
+
+	// field returns the field of record b paired with the field of a, or
+	// nil if there is none.
+	field(b *Record, f *Field) *Field
+
+	// onlyA and onlyB are called for the record fields that are not paired.
+	onlyA(f *Field, path string)
+	onlyB(f *Field, path string)
+
+	symbols(a, b *Enum, path string)
+	size(a, b *Fixed, path string)
+
+	// union is called when either schema is a union.
+	union(w *walker, a, b Schema, path string) error
+
+	// other is called for primitives, logical types and schemas of
+	// different kinds.
+	other(a, b Schema, path string) error
+}
+
+func newWalker(v visitor) *walker {
+	return &walker{v: v, seen: make(map[[2]string]bool)}
+}
+
+// fork returns a walker for the visitor that has seen what w has, so that what
+// it walks is not marked as seen by w.
+func (w *walker) fork(v visitor) *walker {
+	x := &walker{v: v, underlying: w.underlying, seen: make(map[[2]string]bool, len(w.seen))}
+	for k, v := range w.seen {
+		x.seen[k] = v
+	}
+	return x
+}
+
+func (w *walker) walk(a, b Schema, path string) error {
+	a, b = deref(a), deref(b)
+	if w.underlying {
+		a, b = Underlying(a), Underlying(b)
+	}
+
+	_, ua := a.(Union)
+	_, ub := b.(Union)
+	if ua || ub {
+		return w.v.union(w, a, b, path)
+	}
+
+	switch x := a.(type) {
+	case *Record:
+		y, ok := b.(*Record)
+		if !ok {
+			break
+		}
+		if !w.named(x, y, path) {
+			return nil
+		}
+
+		// Fields only in a come first, then those of b in order.
+		pairs := make(map[*Field]*Field)
+		for _, f := range x.Fields {
+			if g := w.v.field(y, f); g != nil {
+				pairs[g] = f
+			} else {
+				w.v.onlyA(f, join(path, f.Name))
+			}
+		}
+		for _, g := range y.Fields {
+			f, ok := pairs[g]
+			if !ok {
+				w.v.onlyB(g, join(path, g.Name))
+				continue
+			}
+			if err := w.walk(f.Type, g.Type, join(path, f.Name)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case *Enum:
+		if y, ok := b.(*Enum); ok {
+			if w.named(x, y, path) {
+				w.v.symbols(x, y, path)
+			}
+			return nil
+		}
+
+	case *Fixed:
+		if y, ok := b.(*Fixed); ok {
+			if w.named(x, y, path) {
+				w.v.size(x, y, path)
+			}
+			return nil
+		}
+
+	case *Array:
+		if y, ok := b.(*Array); ok {
+			return w.walk(x.Items, y.Items, path+"[]")
+		}
+
+	case *Map:
+		if y, ok := b.(*Map); ok {
+			return w.walk(x.Values, y.Values, path+"[]")
+		}
+	}
+
+	return w.v.other(a, b, path)
+}
+
+// named reports whether the pair of named types is to be walked, which it is
+// the first time it is reached if the visitor finds the names match.
+func (w *walker) named(a, b Named, path string) bool {
+	key := [2]string{a.Fullname(), b.Fullname()}
+	if w.seen[key] {
+		return false
+	}
+	w.seen[key] = true
+	return w.v.names(a, b, path)
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(kind ChangeKind, path, old, new string) {
+	d.changes = append(d.changes, Change{Kind: kind, Path: path, Old: old, New: new})
+}
+
+func (d *differ) names(a, b Named, path string) bool {
+	fa, fb := a.Fullname(), b.Fullname()
+	if x, y := shortName(fa), shortName(fb); x != y {
+		d.add(NameChanged, path, x, y)
+	}
+	if x, y := namespaceOf(fa), namespaceOf(fb); x != y {
+		d.add(NamespaceChanged, path, x, y)
+	}
+	return true
+}
+
+func (d *differ) field(b *Record, f *Field) *Field {
+	g, _ := b.Field(f.Name)
+	return g
+}
+
+func (d *differ) onlyA(f *Field, path string) {
+	d.add(FieldRemoved, path, typeString(f.Type), "")
+}
+
+func (d *differ) onlyB(f *Field, path string) {
+	d.add(FieldAdded, path, "", typeString(f.Type))
+}
+
+func (d *differ) symbols(a, b *Enum, path string) {
+	for _, s := range a.Symbols {
+		if _, ok := b.Index(s); !ok {
+			d.add(SymbolRemoved, path, s, "")
+		}
+	}
+	for _, s := range b.Symbols {
+		if _, ok := a.Index(s); !ok {
+			d.add(SymbolAdded, path, "", s)
+		}
+	}
+}
+
+func (d *differ) size(a, b *Fixed, path string) {
+	if a.Size != b.Size {
+		d.add(TypeChanged, path, typeString(a), typeString(b))
+	}
+}
+
+// union pairs the branches of two unions by type. A union and another type
+// are a change of type.
+func (d *differ) union(w *walker, a, b Schema, path string) error {
+	x, ok := a.(Union)
+	y, ok2 := b.(Union)
+	if !ok || !ok2 {
+		return d.other(a, b, path)
+	}
+
+	for _, s := range x {
+		if branch(y, s) == nil {
+			d.add(BranchRemoved, path, typeString(s), "")
+		}
+	}
+	for _, t := range y {
+		s := branch(x, t)
+		if s == nil {
+			d.add(BranchAdded, path, "", typeString(t))
+			continue
+		}
+		w.walk(s, t, path)
+	}
+	return nil
+}
+
+func (d *differ) other(a, b Schema, path string) error {
+	if !Equal(a, b) {
+		d.add(TypeChanged, path, typeString(a), typeString(b))
+	}
+	return nil
+}
+
+// deref returns the schema a reference refers to.
+func deref(s Schema) Schema {
+	if r, ok := s.(*Reference); ok && r.Schema != nil {
+		return r.Schema
+	}
+	return s
+}
+
+// branch returns the branch of the union with the same type as the schema.
+// Named types match by name regardless of namespace, so a branch that moved
+// namespace is compared rather than reported as removed and added.
+func branch(u Union, s Schema) Schema {
+	key := branchKey(s)
+	for _, t := range u {
+		if branchKey(t) == key {
+			return t
+		}
+	}
+	return nil
+}
+
+func branchKey(s Schema) string {
+	if x, ok := deref(s).(Named); ok {
+		fn := x.Fullname()
+		return x.Type() + " " + fn[strings.LastIndex(fn, ".")+1:]
+	}
+	return typeString(s)
+}
+
+// typeString names a type for display: named types by their full name and
+// other types by their JSON form.
+func typeString(s Schema) string {
+	switch x := deref(s).(type) {
+	case Primitive:
+		return string(x)
+	case *AnnotatedPrimitive:
+		return string(x.Primitive)
+	case *Record:
+		return fullname(x.Name, x.Namespace)
+	case *Enum:
+		return fullname(x.Name, x.Namespace)
+	case *Fixed:
+		return fmt.Sprintf("%v(%d)", fullname(x.Name, x.Namespace), x.Size)
+	}
+	return schemaString(s)
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,96 @@
+package avro
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiff(t *testing.T) {
+	a, err := Unmarshal([]byte(`{
+		"type": "record",
+		"name": "Order",
+		"namespace": "com.example",
+		"fields": [
+			{"name": "id", "type": "int"},
+			{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["OPEN", "SHIPPED"]}},
+			{"name": "note", "type": ["null", "string"]},
+			{"name": "lines", "type": {"type": "array", "items": {
+				"type": "record",
+				"name": "Line",
+				"fields": [
+					{"name": "sku", "type": "string"},
+					{"name": "qty", "type": "int"}
+				]
+			}}},
+			{"name": "next", "type": ["null", "Order"]},
+			{"name": "legacy", "type": "string"}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := Unmarshal([]byte(`{
+		"type": "record",
+		"name": "Order",
+		"namespace": "com.example.v2",
+		"doc": "Docs are ignored.",
+		"fields": [
+			{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["SHIPPED", "OPEN", "CLOSED"]}},
+			{"name": "id", "type": "long"},
+			{"name": "note", "type": ["null", "string", "bytes"]},
+			{"name": "lines", "type": {"type": "array", "items": {
+				"type": "record",
+				"name": "Line",
+				"fields": [
+					{"name": "sku", "type": "string"},
+					{"name": "qty", "type": "long"}
+				]
+			}}},
+			{"name": "next", "type": ["null", "Order"]},
+			{"name": "email", "type": "string"}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []Change{
+		{Kind: NamespaceChanged, Old: "com.example", New: "com.example.v2"},
+		{Kind: FieldRemoved, Path: "legacy", Old: "string"},
+		{Kind: NamespaceChanged, Path: "status", Old: "com.example", New: "com.example.v2"},
+		{Kind: SymbolAdded, Path: "status", New: "CLOSED"},
+		{Kind: TypeChanged, Path: "id", Old: "int", New: "long"},
+		{Kind: BranchAdded, Path: "note", New: "bytes"},
+		{Kind: NamespaceChanged, Path: "lines[]", Old: "com.example", New: "com.example.v2"},
+		{Kind: TypeChanged, Path: "lines[].qty", Old: "int", New: "long"},
+		{Kind: FieldAdded, Path: "email", New: "string"},
+	}
+
+	changes := Diff(a, b)
+	if d := cmp.Diff(exp, changes); d != "" {
+		t.Errorf("(-want +got)\n%s", d)
+	}
+
+	lines := []string{
+		`~ namespace (root): "com.example" -> "com.example.v2"`,
+		`- field legacy: string`,
+		`~ namespace status: "com.example" -> "com.example.v2"`,
+		`+ symbol status: CLOSED`,
+		`~ type id: int -> long`,
+		`+ branch note: bytes`,
+		`~ namespace lines[]: "com.example" -> "com.example.v2"`,
+		`~ type lines[].qty: int -> long`,
+		`+ field email: string`,
+	}
+	for i, c := range changes {
+		if i < len(lines) && c.String() != lines[i] {
+			t.Errorf("expected %s, got %s", lines[i], c)
+		}
+	}
+
+	if changes := Diff(a, a); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+}
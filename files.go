@@ -0,0 +1,110 @@
+package avro
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// ParseFiles parses the schema files, keyed by path, whose named types may
+// refer to types defined in the other files. See Parser.ParseFiles.
+func ParseFiles(paths ...string) (map[string]Schema, error) {
+	return new(Parser).ParseFiles(paths...)
+}
+
+// ParseFiles parses the schema files, keyed by path. The named types of all the
+// files share one set of names, so a file may refer to a type defined in
+// another regardless of the order the files are given in. Files are parsed
+// once the types they refer to are defined; an error names the first undefined
+// type and the file that refers to it. A type must not be defined by more than
+// one file, and files cannot refer to each other's types in a cycle.
+func (p *Parser) ParseFiles(paths ...string) (map[string]Schema, error) {
+	docs := make(map[string]interface{}, len(paths))
+	for _, path := range paths {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if p.AllowComments {
+			b = stripComments(b)
+		}
+
+		var v interface{}
+		if err := decodeJSON(b, &v); err != nil {
+			return nil, fmt.Errorf("avroschema: %v: %s", path, err)
+		}
+		docs[path] = v
+	}
+
+	sp := newParser(p)
+	schemas := make(map[string]Schema, len(paths))
+
+	// Each pass parses the files whose references can be resolved, until no
+	// file is left or a pass makes no progress.
+	pending := paths
+	for len(pending) > 0 {
+		var (
+			retry   []string
+			unknown []*unknownTypeError
+		)
+
+		for _, path := range pending {
+			if _, ok := schemas[path]; ok {
+				continue
+			}
+
+			// A file that fails must not leave its types defined.
+			names := make(map[string]Schema, len(sp.names))
+			for k, v := range sp.names {
+				names[k] = v
+			}
+
+			s, err := sp.parse(docs[path], "")
+			if err != nil {
+				sp.names = names
+
+				if e, ok := err.(*unknownTypeError); ok {
+					retry = append(retry, path)
+					unknown = append(unknown, e)
+					continue
+				}
+				return nil, fmt.Errorf("avroschema: %v: %s", path, strings.TrimPrefix(err.Error(), "avroschema: "))
+			}
+			schemas[path] = s
+		}
+
+		if len(retry) == len(pending) {
+			// Prefer a type that no file declares at the top level over one
+			// whose file was itself waiting on an undefined type.
+			i := 0
+			for j, e := range unknown {
+				if !declared(docs, retry, e.name) {
+					i = j
+					break
+				}
+			}
+			return nil, fmt.Errorf("avroschema: undefined type %v referenced in %v", unknown[i].name, retry[i])
+		}
+		pending = retry
+	}
+
+	return schemas, nil
+}
+
+// declared reports whether one of the files declares the named type at the top
+// level, by either its name or its full name.
+func declared(docs map[string]interface{}, paths []string, name string) bool {
+	for _, path := range paths {
+		m, ok := docs[path].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		n, _ := m["name"].(string)
+		ns, _ := m["namespace"].(string)
+		if n != "" && (n == name || fullname(n, ns) == name) {
+			return true
+		}
+	}
+	return false
+}
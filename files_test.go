@@ -0,0 +1,181 @@
+package avro
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes the named files to a temporary directory and returns
+// their paths in the order given.
+func writeFiles(t *testing.T, files ...[2]string) []string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "avro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = filepath.Join(dir, f[0])
+		if err := ioutil.WriteFile(paths[i], []byte(f[1]), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return paths
+}
+
+func TestParseFiles(t *testing.T) {
+	// The order refers to types defined in files given after it.
+	paths := writeFiles(t,
+		[2]string{"order.avsc", `{"type": "record", "name": "Order", "namespace": "com.shop", "fields": [
+			{"name": "customer", "type": "com.people.Customer"},
+			{"name": "status", "type": "Status"}
+		]}`},
+		[2]string{"customer.avsc", `{"type": "record", "name": "Customer", "namespace": "com.people", "fields": [
+			{"name": "address", "type": "com.people.Address"}
+		]}`},
+		[2]string{"address.avsc", `{"type": "record", "name": "Address", "namespace": "com.people", "fields": [
+			{"name": "zip", "type": "string"}
+		]}`},
+		[2]string{"status.avsc", `{"type": "enum", "name": "Status", "namespace": "com.shop", "symbols": ["OPEN", "CLOSED"]}`},
+	)
+
+	schemas, err := ParseFiles(paths...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schemas) != 4 {
+		t.Fatalf("expected 4 schemas, got %d", len(schemas))
+	}
+
+	order := schemas[paths[0]].(*Record)
+	customer := order.Fields[0].Type.(*Reference)
+	if customer.Schema != schemas[paths[1]] {
+		t.Errorf("expected customer to refer to the parsed customer, got %v", customer.Schema)
+	}
+	if status := order.Fields[1].Type.(*Reference); status.Name != "com.shop.Status" || status.Schema != schemas[paths[3]] {
+		t.Errorf("expected status to refer to the parsed enum, got %v", status)
+	}
+}
+
+func TestParseFilesErrors(t *testing.T) {
+	tests := []struct {
+		Files [][2]string
+		Error string
+	}{
+		{
+			[][2]string{
+				{"a.avsc", `{"type": "record", "name": "A", "fields": [{"name": "b", "type": "B"}]}`},
+				{"b.avsc", `{"type": "record", "name": "B", "fields": [{"name": "c", "type": "C"}]}`},
+			},
+			"undefined type C referenced in ",
+		},
+		{
+			[][2]string{
+				{"a.avsc", `{"type": "fixed", "name": "A", "size": 4}`},
+				{"b.avsc", `{"type": "fixed", "name": "A", "size": 8}`},
+			},
+			"b.avsc: type A is defined more than once",
+		},
+		{
+			[][2]string{
+				{"a.avsc", `{"type": "record", "name": "A"`},
+			},
+			"a.avsc: unexpected EOF",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Files[0][0], func(t *testing.T) {
+			_, err := ParseFiles(writeFiles(t, test.Files...)...)
+			checkError(t, err, test.Error)
+		})
+	}
+}
+
+func TestParseFilesCanonicalForm(t *testing.T) {
+	// The same order refers to two different definitions of Money.
+	order := `{"type": "record", "name": "Order", "namespace": "com.shop", "fields": [
+		{"name": "total", "type": "Money"}
+	]}`
+	var orders [2]Schema
+	for i, size := range []string{"8", "16"} {
+		paths := writeFiles(t,
+			[2]string{"order.avsc", order},
+			[2]string{"money.avsc", `{"type": "fixed", "name": "com.shop.Money", "size": ` + size + `}`},
+		)
+		schemas, err := ParseFiles(paths...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		orders[i] = schemas[paths[0]]
+	}
+
+	b, err := CanonicalForm(orders[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{"name":"com.shop.Order","type":"record","fields":[{"name":"total","type":{"name":"com.shop.Money","type":"fixed","size":8}}]}`
+	if string(b) != exp {
+		t.Errorf("expected %s, got %s", exp, b)
+	}
+
+	if eq, err := FingerprintEqual(orders[0], orders[1]); err != nil || eq {
+		t.Errorf("expected different fingerprints, got %v, %v", eq, err)
+	}
+	k0, _ := Key(orders[0])
+	k1, _ := Key(orders[1])
+	if k0 == k1 {
+		t.Errorf("expected different keys, got %s for both", k0)
+	}
+}
+
+func TestParseFilesIDL(t *testing.T) {
+	paths := writeFiles(t,
+		[2]string{"order.avsc", `{"type": "record", "name": "Order", "namespace": "com.shop", "fields": [
+			{"name": "customer", "type": "com.people.Customer"},
+			{"name": "previous", "type": ["null", "com.people.Customer"], "default": null},
+			{"name": "status", "type": "Status"}
+		]}`},
+		[2]string{"customer.avsc", `{"type": "record", "name": "Customer", "namespace": "com.people", "fields": [
+			{"name": "address", "type": "com.people.Address"}
+		]}`},
+		[2]string{"address.avsc", `{"type": "record", "name": "Address", "namespace": "com.people", "fields": [
+			{"name": "zip", "type": "string"}
+		]}`},
+		[2]string{"status.avsc", `{"type": "enum", "name": "Status", "namespace": "com.shop", "symbols": ["OPEN", "CLOSED"]}`},
+	)
+	schemas, err := ParseFiles(paths...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := schemas[paths[0]]
+
+	// The types defined in other files are declared before the order.
+	idl, err := ToIDL(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := ParseIDL(strings.NewReader(idl))
+	if err != nil {
+		t.Fatalf("%v in:\n%s", err, idl)
+	}
+
+	var names []string
+	for _, s := range p.Types {
+		names = append(names, s.(Named).Fullname())
+	}
+	exp := []string{"com.people.Address", "com.people.Customer", "com.shop.Status", "com.shop.Order"}
+	if !reflect.DeepEqual(names, exp) {
+		t.Errorf("expected types %v, got %v", exp, names)
+	}
+	if changes := Diff(order, p.Types[3]); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+}
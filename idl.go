@@ -0,0 +1,412 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// idlKeywords are the identifiers reserved by Avro IDL, which must be quoted
+// with backticks to be used as names.
+var idlKeywords = map[string]bool{
+	"array": true, "boolean": true, "bytes": true, "date": true, "decimal": true,
+	"double": true, "enum": true, "error": true, "false": true, "fixed": true,
+	"float": true, "idl": true, "import": true, "int": true, "long": true,
+	"map": true, "null": true, "oneway": true, "protocol": true, "record": true,
+	"schema": true, "string": true, "throws": true, "time_ms": true,
+	"timestamp_ms": true, "true": true, "union": true, "void": true,
+}
+
+// ToIDL renders the schema as an Avro IDL protocol named after the top-level
+// type, which must be a named type. Every named type is declared once, before
+// the types that use it where possible, and referred to by name thereafter.
+// Docs, defaults, aliases, sort orders and custom attributes are kept, and
+// logical types are written with their IDL keyword or a @logicalType
+// annotation.
+// https://avro.apache.org/docs/current/idl.html
+func ToIDL(s Schema) (string, error) {
+	n, ok := deref(s).(Named)
+	if !ok {
+		return "", fmt.Errorf("avroschema: IDL requires a named type, not %v", s.Type())
+	}
+
+	name := n.Fullname()
+	namespace := namespaceOf(name)
+
+	w := idlWriter{
+		namespace: namespace,
+		declared:  make(map[string]bool),
+	}
+
+	if namespace != "" {
+		fmt.Fprintf(&w.buf, "@namespace(%q)\n", namespace)
+	}
+	fmt.Fprintf(&w.buf, "protocol %v {\n", idlName(name[strings.LastIndex(name, ".")+1:]))
+
+	if err := w.declare(s, namespace); err != nil {
+		return "", err
+	}
+
+	w.buf.WriteString("}\n")
+	return w.buf.String(), nil
+}
+
+// idlWriter writes the declarations of a protocol.
+type idlWriter struct {
+	buf       strings.Builder
+	namespace string
+	declared  map[string]bool
+	started   bool
+}
+
+// declare writes the declarations of the named types within the schema that
+// have not been declared yet. Types are declared after the types they use.
+func (w *idlWriter) declare(s Schema, namespace string) error {
+	switch x := s.(type) {
+	case *Record:
+		fn := fullname(x.Name, w.inherit(x.Namespace, namespace))
+		if w.declared[fn] {
+			return nil
+		}
+		w.declared[fn] = true
+
+		for _, f := range x.Fields {
+			if err := w.declare(f.Type, namespaceOf(fn)); err != nil {
+				return err
+			}
+		}
+		return w.writeRecord(x, fn)
+
+	case *Enum:
+		fn := fullname(x.Name, w.inherit(x.Namespace, namespace))
+		if w.declared[fn] {
+			return nil
+		}
+		w.declared[fn] = true
+		return w.writeEnum(x, fn)
+
+	case *Fixed:
+		fn := fullname(x.Name, w.inherit(x.Namespace, namespace))
+		if w.declared[fn] {
+			return nil
+		}
+		w.declared[fn] = true
+		return w.writeFixed(x, fn, nil)
+
+	case *FixedDecimal:
+		fn := fullname(x.Fixed.Name, w.inherit(x.Fixed.Namespace, namespace))
+		if w.declared[fn] {
+			return nil
+		}
+		w.declared[fn] = true
+
+		props := map[string]interface{}{
+			"logicalType": "decimal",
+			"precision":   x.Precision,
+			"scale":       x.Scale,
+		}
+		for k, v := range x.Fixed.Props {
+			props[k] = v
+		}
+		return w.writeFixed(x.Fixed, fn, props)
+
+	case *duration:
+		// Durations are fixed types, which must be declared.
+		f := x.fixed()
+		fn := fullname(f.Name, w.inherit(f.Namespace, namespace))
+		if w.declared[fn] {
+			return nil
+		}
+		w.declared[fn] = true
+
+		props := map[string]interface{}{"logicalType": "duration"}
+		for k, v := range f.Props {
+			props[k] = v
+		}
+		return w.writeFixed(f, fn, props)
+
+	case *Array:
+		return w.declare(x.Items, namespace)
+
+	case *Map:
+		return w.declare(x.Values, namespace)
+
+	case Union:
+		for _, t := range x {
+			if err := w.declare(t, namespace); err != nil {
+				return err
+			}
+		}
+
+	case *Reference:
+		// A type defined elsewhere, such as in another file, is declared
+		// where it is first reached.
+		if x.Schema != nil && !w.declared[x.Name] {
+			return w.declare(x.Schema, namespaceOf(x.Name))
+		}
+	}
+
+	return nil
+}
+
+func (w *idlWriter) inherit(namespace, enclosing string) string {
+	if namespace != "" {
+		return namespace
+	}
+	return enclosing
+}
+
+// start separates declarations with a blank line and writes the annotations
+// common to named types.
+func (w *idlWriter) start(doc, fullname string, aliases []string, props map[string]interface{}) error {
+	if w.started {
+		w.buf.WriteByte('\n')
+	}
+	w.started = true
+
+	w.writeDoc(doc, "  ")
+
+	if ns := namespaceOf(fullname); ns != w.namespace {
+		fmt.Fprintf(&w.buf, "  @namespace(%q)\n", ns)
+	}
+	if len(aliases) > 0 {
+		w.buf.WriteString("  ")
+		if err := w.writeAnnotation("aliases", aliases); err != nil {
+			return err
+		}
+		w.buf.WriteString("\n")
+	}
+	for _, k := range sortedKeys(props) {
+		w.buf.WriteString("  ")
+		if err := w.writeAnnotation(k, props[k]); err != nil {
+			return err
+		}
+		w.buf.WriteString("\n")
+	}
+
+	return nil
+}
+
+func (w *idlWriter) writeRecord(r *Record, fn string) error {
+	if err := w.start(r.Doc, fn, r.Aliases, r.Props); err != nil {
+		return err
+	}
+	kw := "record"
+	if r.Error {
+		kw = "error"
+	}
+	fmt.Fprintf(&w.buf, "  %v %v {\n", kw, idlName(r.Name[strings.LastIndex(r.Name, ".")+1:]))
+
+	for _, f := range r.Fields {
+		w.writeDoc(f.Doc, "    ")
+
+		t, err := w.typeName(f.Type, namespaceOf(fn))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&w.buf, "    %v ", t)
+
+		if f.Order != "" {
+			if err := w.writeAnnotation("order", f.Order); err != nil {
+				return err
+			}
+			w.buf.WriteByte(' ')
+		}
+		if len(f.Aliases) > 0 {
+			if err := w.writeAnnotation("aliases", f.Aliases); err != nil {
+				return err
+			}
+			w.buf.WriteByte(' ')
+		}
+		for _, k := range sortedKeys(f.Props) {
+			if err := w.writeAnnotation(k, f.Props[k]); err != nil {
+				return err
+			}
+			w.buf.WriteByte(' ')
+		}
+
+		w.buf.WriteString(idlName(f.Name))
+
+		if f.HasDefault() {
+			b, err := json.Marshal(f.Default)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&w.buf, " = %s", b)
+		}
+		w.buf.WriteString(";\n")
+	}
+
+	w.buf.WriteString("  }\n")
+	return nil
+}
+
+func (w *idlWriter) writeEnum(e *Enum, fn string) error {
+	if err := w.start(e.Doc, fn, e.Aliases, e.Props); err != nil {
+		return err
+	}
+	fmt.Fprintf(&w.buf, "  enum %v {\n", idlName(e.Name[strings.LastIndex(e.Name, ".")+1:]))
+
+	syms := make([]string, len(e.Symbols))
+	for i, s := range e.Symbols {
+		syms[i] = idlName(s)
+	}
+	fmt.Fprintf(&w.buf, "    %v\n  }", strings.Join(syms, ", "))
+
+	if e.Default != "" {
+		fmt.Fprintf(&w.buf, " = %v;", idlName(e.Default))
+	}
+	w.buf.WriteString("\n")
+	return nil
+}
+
+func (w *idlWriter) writeFixed(f *Fixed, fn string, props map[string]interface{}) error {
+	if props == nil {
+		props = f.Props
+	}
+	if err := w.start("", fn, f.Aliases, props); err != nil {
+		return err
+	}
+	fmt.Fprintf(&w.buf, "  fixed %v(%d);\n", idlName(f.Name[strings.LastIndex(f.Name, ".")+1:]), f.Size)
+	return nil
+}
+
+func (w *idlWriter) writeDoc(doc, indent string) {
+	if doc == "" {
+		return
+	}
+	fmt.Fprintf(&w.buf, "%v/** %v */\n", indent, strings.Replace(doc, "*/", "* /", -1))
+}
+
+func (w *idlWriter) writeAnnotation(key string, value interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(&w.buf, "@%v(%s)", key, b)
+	return nil
+}
+
+// typeName returns how the schema is written where it is used.
+func (w *idlWriter) typeName(s Schema, namespace string) (string, error) {
+	switch x := s.(type) {
+	case Primitive:
+		return string(x), nil
+
+	case *AnnotatedPrimitive:
+		return w.annotated(x.Props, string(x.Primitive))
+
+	case *Record:
+		return w.ref(fullname(x.Name, w.inherit(x.Namespace, namespace))), nil
+
+	case *Enum:
+		return w.ref(fullname(x.Name, w.inherit(x.Namespace, namespace))), nil
+
+	case *Fixed:
+		return w.ref(fullname(x.Name, w.inherit(x.Namespace, namespace))), nil
+
+	case *Reference:
+		return w.ref(x.Name), nil
+
+	case *Array:
+		t, err := w.typeName(x.Items, namespace)
+		if err != nil {
+			return "", err
+		}
+		return w.annotated(x.Props, "array<"+t+">")
+
+	case *Map:
+		t, err := w.typeName(x.Values, namespace)
+		if err != nil {
+			return "", err
+		}
+		return w.annotated(x.Props, "map<"+t+">")
+
+	case Union:
+		ts := make([]string, len(x))
+		for i, b := range x {
+			t, err := w.typeName(b, namespace)
+			if err != nil {
+				return "", err
+			}
+			ts[i] = t
+		}
+		return "union { " + strings.Join(ts, ", ") + " }", nil
+
+	case *Decimal:
+		return fmt.Sprintf("decimal(%d, %d)", x.Precision, x.Scale), nil
+
+	case *annotatedDecimal:
+		return w.annotated(x.Props, fmt.Sprintf("decimal(%d, %d)", x.Precision, x.Scale))
+
+	case *FixedDecimal:
+		return w.ref(fullname(x.Fixed.Name, w.inherit(x.Fixed.Namespace, namespace))), nil
+
+	case *date:
+		return w.annotated(x.Props, "date")
+
+	case *timeMillis:
+		return w.annotated(x.Props, "time_ms")
+
+	case *timestampMillis:
+		return w.annotated(x.Props, "timestamp_ms")
+
+	case *timeMicros:
+		return w.annotated(x.Props, `@logicalType("time-micros") long`)
+
+	case *timestampMicros:
+		return w.annotated(x.Props, `@logicalType("timestamp-micros") long`)
+
+	case *duration:
+		f := x.fixed()
+		return w.ref(fullname(f.Name, w.inherit(f.Namespace, namespace))), nil
+
+	case *UnknownLogical:
+		return w.annotated(x.Props, fmt.Sprintf("@logicalType(%q) %v", x.Name, x.Backing))
+	}
+
+	if u := Underlying(s); u != s {
+		return w.typeName(u, namespace)
+	}
+
+	return "", fmt.Errorf("avroschema: unsupported schema type %v", s.Type())
+}
+
+// ref returns the name a named type is referred to by, which is relative to
+// the protocol namespace.
+func (w *idlWriter) ref(fullname string) string {
+	if namespaceOf(fullname) == w.namespace {
+		return idlName(fullname[strings.LastIndex(fullname, ".")+1:])
+	}
+	return fullname
+}
+
+func (w *idlWriter) annotated(props map[string]interface{}, t string) (string, error) {
+	var b strings.Builder
+	for _, k := range sortedKeys(props) {
+		v, err := json.Marshal(props[k])
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "@%v(%s) ", k, v)
+	}
+	return b.String() + t, nil
+}
+
+// idlName quotes the identifier if it is an IDL keyword.
+func idlName(s string) string {
+	if idlKeywords[s] {
+		return "`" + s + "`"
+	}
+	return s
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
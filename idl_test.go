@@ -0,0 +1,229 @@
+package avro
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestToIDL(t *testing.T) {
+	s, err := Unmarshal([]byte(`{
+		"type": "record",
+		"name": "Order",
+		"namespace": "com.example",
+		"doc": "An order.",
+		"fields": [
+			{"name": "id", "type": "long", "order": "descending"},
+			{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["OPEN", "CLOSED"], "default": "OPEN"}, "default": "OPEN"},
+			{"name": "hash", "type": {"type": "fixed", "name": "Hash", "namespace": "com.other", "size": 16}},
+			{"name": "lines", "type": {"type": "array", "items": {
+				"type": "record",
+				"name": "Line",
+				"fields": [
+					{"name": "sku", "type": "string", "doc": "Stock unit."},
+					{"name": "price", "type": {"type": "bytes", "logicalType": "decimal", "precision": 9, "scale": 2}}
+				]
+			}}},
+			{"name": "tags", "type": {"type": "map", "values": "string"}, "aliases": ["labels"], "x-db": "tags"},
+			{"name": "placed", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+			{"name": "error", "type": ["null", "Order"], "default": null}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "@namespace(\"com.example\")\n" +
+		"protocol Order {\n" +
+		"  enum Status {\n" +
+		"    OPEN, CLOSED\n" +
+		"  } = OPEN;\n" +
+		"\n" +
+		"  @namespace(\"com.other\")\n" +
+		"  fixed Hash(16);\n" +
+		"\n" +
+		"  record Line {\n" +
+		"    /** Stock unit. */\n" +
+		"    string sku;\n" +
+		"    decimal(9, 2) price;\n" +
+		"  }\n" +
+		"\n" +
+		"  /** An order. */\n" +
+		"  record Order {\n" +
+		"    long @order(\"descending\") id;\n" +
+		"    Status status = \"OPEN\";\n" +
+		"    com.other.Hash hash;\n" +
+		"    array<Line> lines;\n" +
+		"    map<string> @aliases([\"labels\"]) @x-db(\"tags\") tags;\n" +
+		"    timestamp_ms placed;\n" +
+		"    union { null, Order } `error` = null;\n" +
+		"  }\n" +
+		"}\n"
+
+	idl, err := ToIDL(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idl != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, idl)
+	}
+
+	if _, err := ToIDL(&Array{Items: Int}); err == nil {
+		t.Errorf("expected error for unnamed type")
+	}
+}
+
+func TestParseIDL(t *testing.T) {
+	idl := `/** Orders placed by customers. */
+@namespace("com.example")
+protocol Shop {
+  // Enums may have a default.
+  enum Status {
+    OPEN, CLOSED
+  } = OPEN;
+
+  @namespace("com.other")
+  fixed Hash(16);
+
+  /**
+   * A line of an order.
+   */
+  record Line {
+    string sku;
+    decimal(9, 2) price;
+    int qty = 1, min = 0;
+  }
+
+  @aliases(["Purchase"])
+  record Order {
+    long @order("descending") id;
+    Status status = "OPEN";
+    com.other.Hash hash;
+    array<Line> lines;
+    map<string> @x-db("tags") tags;
+    timestamp_ms placed;
+    @logicalType("timestamp-micros") long updated;
+    string? note;
+    int? count = 0;
+    union { null, Order } ` + "`error`" + ` = null;
+  }
+}
+`
+
+	p, err := ParseIDL(strings.NewReader(idl))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Protocol != "Shop" || p.Namespace != "com.example" || p.Doc != "Orders placed by customers." {
+		t.Errorf("unexpected protocol %q namespace %q doc %q", p.Protocol, p.Namespace, p.Doc)
+	}
+	if len(p.Types) != 4 {
+		t.Fatalf("expected 4 types, got %d", len(p.Types))
+	}
+
+	exp := []string{
+		`{"type":"enum","name":"Status","namespace":"com.example","symbols":["OPEN","CLOSED"],"default":"OPEN"}`,
+		`{"type":"fixed","name":"Hash","namespace":"com.other","size":16}`,
+		`{"type":"record","name":"Line","namespace":"com.example","doc":"A line of an order.","fields":[` +
+			`{"name":"sku","type":"string"},` +
+			`{"name":"price","type":{"type":"bytes","logicalType":"decimal","precision":9,"scale":2}},` +
+			`{"name":"qty","type":"int","default":1},` +
+			`{"name":"min","type":"int","default":0}]}`,
+		`{"type":"record","name":"Order","namespace":"com.example","aliases":["Purchase"],"fields":[` +
+			`{"name":"id","type":"long","order":"descending"},` +
+			`{"name":"status","type":"com.example.Status","default":"OPEN"},` +
+			`{"name":"hash","type":"com.other.Hash"},` +
+			`{"name":"lines","type":{"type":"array","items":"com.example.Line"}},` +
+			`{"name":"tags","type":{"type":"map","values":"string"},"x-db":"tags"},` +
+			`{"name":"placed","type":{"type":"long","logicalType":"timestamp-millis"}},` +
+			`{"name":"updated","type":{"type":"long","logicalType":"timestamp-micros"}},` +
+			`{"name":"note","type":["null","string"]},` +
+			`{"name":"count","type":["int","null"],"default":0},` +
+			`{"name":"error","type":["null","com.example.Order"],"default":null}]}`,
+	}
+
+	for i, s := range p.Types {
+		b, err := Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != exp[i] {
+			t.Errorf("expected %s, got %s", exp[i], b)
+		}
+	}
+}
+
+func TestParseIDLRoundTrip(t *testing.T) {
+	s, err := Unmarshal([]byte(`{
+		"type": "record",
+		"name": "Node",
+		"namespace": "com.example",
+		"doc": "A tree node.",
+		"fields": [
+			{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["LEAF", "BRANCH"]}},
+			{"name": "id", "type": {"type": "fixed", "name": "Id", "namespace": "com.ids", "size": 8}},
+			{"name": "children", "type": {"type": "array", "items": "Node"}, "default": []},
+			{"name": "attrs", "type": ["null", {"type": "map", "values": "bytes"}], "default": null},
+			{"name": "label", "type": {"type": "string", "java-class": "com.example.Label"}},
+			{"name": "age", "type": {"type": "fixed", "name": "Age", "size": 12, "logicalType": "duration"}},
+			{"name": "weight", "type": {"type": "fixed", "name": "Weight", "size": 4, "logicalType": "decimal", "precision": 9, "scale": 3}}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idl, err := ToIDL(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := ParseIDL(strings.NewReader(idl))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Named types are declared separately, so the top-level type, which is
+	// declared last, refers to them rather than defining them inline.
+	if len(p.Types) != 5 {
+		t.Fatalf("expected 5 types, got %d", len(p.Types))
+	}
+	if changes := Diff(s, p.Types[4]); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+	r := p.Types[4].(*Record)
+	if r.Doc != "A tree node." || !Equal(deref(r.Fields[1].Type), &Fixed{Name: "Id", Namespace: "com.ids", Size: 8}) {
+		t.Errorf("unexpected record %v", r)
+	}
+	if a, ok := r.Fields[4].Type.(*AnnotatedPrimitive); !ok || a.Props["java-class"] != "com.example.Label" {
+		t.Errorf("expected annotated string, got %v", r.Fields[4].Type)
+	}
+	if !Equal(r.Fields[5].Type, &duration{Fixed: &Fixed{Name: "Age", Namespace: "com.example", Size: 12}}) {
+		t.Errorf("expected duration, got %v", r.Fields[5].Type)
+	}
+}
+
+func TestParseIDLErrors(t *testing.T) {
+	tests := []struct {
+		IDL   string
+		Error string
+	}{
+		{"protocol P {\n  record R {\n    int a\n  }\n}", `idl:4:3: expected ";", found "}"`},
+		{"protocol P {\n  record R { Missing m; }\n}", `idl:2:3: unknown type Missing`},
+		{"protocol P {\n  void ping();\n}", `idl:2:3: messages are not supported`},
+		{"protocol P {\n  Greeting hello(string name);\n}", `idl:2:3: messages are not supported`},
+		{"protocol P {\n  recrod R {}\n}", `idl:2:3: expected declaration, found "recrod"`},
+		{"protocol P {\n  fixed F(x);\n}", `idl:2:11: expected number, found "x"`},
+		{"protocol P {\n  enum E { A, B\n", `idl:3:1: expected ",", found end of input`},
+		{"protocol P { /* open", `idl:1:14: unterminated comment`},
+		{"record R {}", `idl:1:1: expected "protocol", found "record"`},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			_, err := ParseIDL(strings.NewReader(test.IDL))
+			checkError(t, err, test.Error)
+		})
+	}
+}
@@ -0,0 +1,817 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseIDL parses an Avro IDL protocol. Records, errors, enums, fixed types,
+// arrays, maps, unions, nullable types written as type?, logical types and
+// annotations are supported; messages and imports are not. Type names are
+// resolved as they are in schemas, with short names referring to types in the
+// protocol namespace, and types must be declared before they are used outside
+// their own declaration. Errors give the line and column of the problem.
+// https://avro.apache.org/docs/current/idl.html
+func ParseIDL(r io.Reader) (*Protocol, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	toks, err := lexIDL(string(b))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &idlParser{toks: toks, declared: make(map[string]bool)}
+	return p.parseProtocol()
+}
+
+// Token kinds other than punctuation, which is its own character.
+const (
+	idlEOF = -(iota + 1)
+	idlIdent
+	idlString
+	idlNumber
+)
+
+type idlToken struct {
+	kind rune
+	text string
+	line int
+	col  int
+
+	// doc is the text of the doc comment immediately preceding the token.
+	doc string
+}
+
+func (t idlToken) String() string {
+	switch t.kind {
+	case idlEOF:
+		return "end of input"
+	case idlString:
+		return t.text
+	}
+	return strconv.Quote(t.text)
+}
+
+// lexIDL splits the input into tokens, dropping whitespace and comments.
+func lexIDL(s string) ([]idlToken, error) {
+	var (
+		toks      []idlToken
+		doc       string
+		line, col = 1, 1
+		rs        = []rune(s)
+	)
+
+	errorf := func(format string, args ...interface{}) error {
+		return fmt.Errorf("avroschema: idl:%d:%d: %s", line, col, fmt.Sprintf(format, args...))
+	}
+
+	// advance moves past n runes, tracking the position.
+	advance := func(i, n int) int {
+		for ; n > 0 && i < len(rs); n-- {
+			if rs[i] == '\n' {
+				line++
+				col = 1
+			} else {
+				col++
+			}
+			i++
+		}
+		return i
+	}
+
+	// at reports whether the input at rune i starts with the prefix, without
+	// copying the rest of the input.
+	at := func(i int, prefix string) bool {
+		for _, r := range prefix {
+			if i >= len(rs) || rs[i] != r {
+				return false
+			}
+			i++
+		}
+		return true
+	}
+
+	isIdent := func(r rune) bool {
+		return r == '_' || r == '.' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+
+	for i := 0; i < len(rs); {
+		r := rs[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i = advance(i, 1)
+
+		case at(i, "//"):
+			for i < len(rs) && rs[i] != '\n' {
+				i = advance(i, 1)
+			}
+
+		case at(i, "/*"):
+			j := i + 2
+			for ; j < len(rs) && !at(j, "*/"); j++ {
+			}
+			if j >= len(rs) {
+				return nil, errorf("unterminated comment")
+			}
+			comment := string(rs[i : j+2])
+			if strings.HasPrefix(comment, "/**") && comment != "/**/" {
+				doc = idlDoc(comment)
+			}
+			i = advance(i, j+2-i)
+
+		default:
+			tok := idlToken{line: line, col: col, doc: doc}
+			doc = ""
+
+			switch {
+			case r == '`':
+				j := i + 1
+				for ; j < len(rs) && rs[j] != '`'; j++ {
+				}
+				if j >= len(rs) {
+					return nil, errorf("unterminated quoted identifier")
+				}
+				tok.kind = idlIdent
+				tok.text = string(rs[i+1 : j])
+				i = advance(i, j+1-i)
+
+			case r == '"':
+				j := i + 1
+				for ; j < len(rs) && rs[j] != '"'; j++ {
+					if rs[j] == '\\' {
+						j++
+					}
+				}
+				if j >= len(rs) {
+					return nil, errorf("unterminated string")
+				}
+				tok.kind = idlString
+				tok.text = string(rs[i : j+1])
+				i = advance(i, j+1-i)
+
+			case r == '-' || unicode.IsDigit(r):
+				j := i + 1
+				for ; j < len(rs) && strings.ContainsRune("0123456789.eE+-", rs[j]); j++ {
+				}
+				tok.kind = idlNumber
+				tok.text = string(rs[i:j])
+				if _, err := strconv.ParseFloat(tok.text, 64); err != nil {
+					return nil, errorf("invalid number %v", tok.text)
+				}
+				i = advance(i, j-i)
+
+			case isIdent(r):
+				j := i
+				for ; j < len(rs) && isIdent(rs[j]); j++ {
+				}
+				tok.kind = idlIdent
+				tok.text = string(rs[i:j])
+				i = advance(i, j-i)
+
+			case strings.ContainsRune("{}()<>[],;=@:?", r):
+				tok.kind = r
+				tok.text = string(r)
+				i = advance(i, 1)
+
+			default:
+				return nil, errorf("unexpected character %q", r)
+			}
+
+			toks = append(toks, tok)
+		}
+	}
+
+	return append(toks, idlToken{kind: idlEOF, line: line, col: col}), nil
+}
+
+// idlDoc returns the text of a doc comment without its delimiters and the
+// leading asterisks of continuation lines.
+func idlDoc(comment string) string {
+	lines := strings.Split(comment[3:len(comment)-2], "\n")
+	for i, l := range lines {
+		l = strings.TrimSpace(l)
+		if i > 0 {
+			l = strings.TrimSpace(strings.TrimPrefix(l, "*"))
+		}
+		lines[i] = l
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// idlParser parses IDL tokens into the JSON form of the declared types, which
+// is then parsed as a schema.
+type idlParser struct {
+	toks []idlToken
+	pos  int
+
+	namespace string
+	declared  map[string]bool
+}
+
+// idlRef is a reference to a named type, qualified once all declarations
+// are known.
+type idlRef string
+
+// idlDecl is a type declaration and where it starts.
+type idlDecl struct {
+	tok idlToken
+	m   map[string]interface{}
+}
+
+func (p *idlParser) peek() idlToken {
+	return p.toks[p.pos]
+}
+
+func (p *idlParser) next() idlToken {
+	t := p.toks[p.pos]
+	if t.kind != idlEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *idlParser) errorf(t idlToken, format string, args ...interface{}) error {
+	return fmt.Errorf("avroschema: idl:%d:%d: %s", t.line, t.col, fmt.Sprintf(format, args...))
+}
+
+// expect consumes a token of the kind. If text is set, the token must also be
+// that punctuation or keyword.
+func (p *idlParser) expect(kind rune, text string) (idlToken, error) {
+	t := p.next()
+	if t.kind == kind && (text == "" || t.text == text) {
+		return t, nil
+	}
+
+	switch {
+	case text != "":
+		text = strconv.Quote(text)
+	case kind == idlNumber:
+		text = "number"
+	case kind == idlString:
+		text = "string"
+	default:
+		text = "identifier"
+	}
+	return t, p.errorf(t, "expected %v, found %v", text, t)
+}
+
+// accept consumes the punctuation token if it is next.
+func (p *idlParser) accept(kind rune) bool {
+	if p.peek().kind == kind {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *idlParser) parseProtocol() (*Protocol, error) {
+	start := p.peek()
+
+	annotations, err := p.parseAnnotations()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := p.expect(idlIdent, "protocol"); err != nil {
+		return nil, err
+	}
+	name, err := p.expect(idlIdent, "")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect('{', "{"); err != nil {
+		return nil, err
+	}
+
+	proto := &Protocol{
+		Protocol: name.text,
+		Doc:      start.doc,
+	}
+	if ns, ok := annotations["namespace"].(string); ok {
+		proto.Namespace = ns
+		p.namespace = ns
+	}
+
+	var decls []idlDecl
+	for !p.accept('}') {
+		d, err := p.parseDecl()
+		if err != nil {
+			return nil, err
+		}
+		decls = append(decls, d)
+	}
+
+	if t := p.next(); t.kind != idlEOF {
+		return nil, p.errorf(t, "expected end of input, found %v", t)
+	}
+
+	// Resolve the declarations as schemas sharing a set of defined names.
+	sp := newParser(&Parser{})
+	for _, d := range decls {
+		s, err := sp.parseObject(p.qualify(d.m).(map[string]interface{}), "")
+		if err != nil {
+			return nil, p.errorf(d.tok, "%s", strings.TrimPrefix(err.Error(), "avroschema: "))
+		}
+		proto.Types = append(proto.Types, s)
+	}
+
+	return proto, nil
+}
+
+// parseAnnotations parses a sequence of @name(value) annotations.
+func (p *idlParser) parseAnnotations() (map[string]interface{}, error) {
+	var m map[string]interface{}
+
+	for p.accept('@') {
+		name, err := p.expect(idlIdent, "")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect('(', "("); err != nil {
+			return nil, err
+		}
+		v, err := p.parseJSON()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(')', ")"); err != nil {
+			return nil, err
+		}
+
+		if m == nil {
+			m = make(map[string]interface{})
+		}
+		m[name.text] = v
+	}
+
+	return m, nil
+}
+
+// parseDecl parses a named type declaration.
+func (p *idlParser) parseDecl() (idlDecl, error) {
+	start := p.peek()
+
+	annotations, err := p.parseAnnotations()
+	if err != nil {
+		return idlDecl{}, err
+	}
+
+	kw := p.next()
+	if kw.kind != idlIdent {
+		return idlDecl{}, p.errorf(kw, "expected declaration, found %v", kw)
+	}
+
+	var m map[string]interface{}
+	switch kw.text {
+	case "record", "error":
+		m, err = p.parseRecord(kw.text)
+	case "enum":
+		m, err = p.parseEnum()
+	case "fixed":
+		m, err = p.parseFixed()
+	case "import":
+		return idlDecl{}, p.errorf(kw, "imports are not supported")
+	default:
+		// A message is a type followed by its name and parameters.
+		if p.peek().kind == idlIdent && p.toks[p.pos+1].kind == '(' {
+			return idlDecl{}, p.errorf(kw, "messages are not supported")
+		}
+		return idlDecl{}, p.errorf(kw, "expected declaration, found %v", kw)
+	}
+	if err != nil {
+		return idlDecl{}, err
+	}
+
+	if start.doc != "" {
+		m["doc"] = start.doc
+	}
+
+	// Declarations are in the protocol namespace unless annotated otherwise.
+	ns, _ := annotations["namespace"].(string)
+	delete(annotations, "namespace")
+	if ns == "" {
+		ns = p.namespace
+	}
+	if ns != "" {
+		m["namespace"] = ns
+	}
+	p.declared[fullname(m["name"].(string), ns)] = true
+
+	for k, v := range annotations {
+		m[k] = v
+	}
+
+	return idlDecl{tok: start, m: m}, nil
+}
+
+func (p *idlParser) parseRecord(typ string) (map[string]interface{}, error) {
+	name, err := p.expect(idlIdent, "")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect('{', "{"); err != nil {
+		return nil, err
+	}
+
+	fields := []interface{}{}
+	for !p.accept('}') {
+		fs, err := p.parseFields()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, fs...)
+	}
+
+	return map[string]interface{}{
+		"type":   typ,
+		"name":   name.text,
+		"fields": fields,
+	}, nil
+}
+
+// parseFields parses a field declaration, which may declare several fields of
+// the same type.
+func (p *idlParser) parseFields() ([]interface{}, error) {
+	doc := p.peek().doc
+
+	t, nullable, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []interface{}
+	for {
+		annotations, err := p.parseAnnotations()
+		if err != nil {
+			return nil, err
+		}
+
+		name, err := p.expect(idlIdent, "")
+		if err != nil {
+			return nil, err
+		}
+
+		f := map[string]interface{}{
+			"name": name.text,
+		}
+		for k, v := range annotations {
+			f[k] = v
+		}
+		if doc != "" {
+			f["doc"] = doc
+		}
+
+		if p.accept('=') {
+			d, err := p.parseJSON()
+			if err != nil {
+				return nil, err
+			}
+			f["default"] = d
+		}
+
+		// A nullable type is a union with null, which comes first unless the
+		// default is not null.
+		ft := t
+		if d, ok := f["default"]; nullable && ok && d != nil {
+			ft = []interface{}{t, "null"}
+		} else if nullable {
+			ft = []interface{}{"null", t}
+		}
+		f["type"] = ft
+
+		fields = append(fields, f)
+
+		if !p.accept(',') {
+			break
+		}
+	}
+
+	if _, err := p.expect(';', ";"); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func (p *idlParser) parseEnum() (map[string]interface{}, error) {
+	name, err := p.expect(idlIdent, "")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect('{', "{"); err != nil {
+		return nil, err
+	}
+
+	symbols := []interface{}{}
+	for !p.accept('}') {
+		if len(symbols) > 0 {
+			if _, err := p.expect(',', ","); err != nil {
+				return nil, err
+			}
+		}
+		sym, err := p.expect(idlIdent, "")
+		if err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, sym.text)
+	}
+
+	m := map[string]interface{}{
+		"type":    "enum",
+		"name":    name.text,
+		"symbols": symbols,
+	}
+
+	if p.accept('=') {
+		d, err := p.expect(idlIdent, "")
+		if err != nil {
+			return nil, err
+		}
+		m["default"] = d.text
+		if _, err := p.expect(';', ";"); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+func (p *idlParser) parseFixed() (map[string]interface{}, error) {
+	name, err := p.expect(idlIdent, "")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect('(', "("); err != nil {
+		return nil, err
+	}
+	size, err := p.expect(idlNumber, "")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(')', ")"); err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(';', ";"); err != nil {
+		return nil, err
+	}
+
+	n, _ := strconv.ParseFloat(size.text, 64)
+	return map[string]interface{}{
+		"type": "fixed",
+		"name": name.text,
+		"size": n,
+	}, nil
+}
+
+// parseType parses a type, reporting whether it is marked nullable with ?.
+func (p *idlParser) parseType() (interface{}, bool, error) {
+	annotations, err := p.parseAnnotations()
+	if err != nil {
+		return nil, false, err
+	}
+
+	t := p.next()
+	if t.kind != idlIdent {
+		return nil, false, p.errorf(t, "expected type, found %v", t)
+	}
+
+	var v interface{}
+	switch t.text {
+	case "null", "boolean", "int", "long", "float", "double", "bytes", "string":
+		v = t.text
+
+	case "date":
+		v = map[string]interface{}{"type": "int", "logicalType": "date"}
+	case "time_ms":
+		v = map[string]interface{}{"type": "int", "logicalType": "time-millis"}
+	case "timestamp_ms":
+		v = map[string]interface{}{"type": "long", "logicalType": "timestamp-millis"}
+	case "uuid":
+		v = map[string]interface{}{"type": "string", "logicalType": "uuid"}
+
+	case "decimal":
+		var args [2]float64
+		for i := range args {
+			sep := rune('(')
+			if i > 0 {
+				sep = ','
+			}
+			if _, err := p.expect(sep, string(sep)); err != nil {
+				return nil, false, err
+			}
+			n, err := p.expect(idlNumber, "")
+			if err != nil {
+				return nil, false, err
+			}
+			args[i], _ = strconv.ParseFloat(n.text, 64)
+		}
+		if _, err := p.expect(')', ")"); err != nil {
+			return nil, false, err
+		}
+		v = map[string]interface{}{"type": "bytes", "logicalType": "decimal", "precision": args[0], "scale": args[1]}
+
+	case "array", "map":
+		if _, err := p.expect('<', "<"); err != nil {
+			return nil, false, err
+		}
+		et, err := p.parseNonNullable()
+		if err != nil {
+			return nil, false, err
+		}
+		if _, err := p.expect('>', ">"); err != nil {
+			return nil, false, err
+		}
+		if t.text == "array" {
+			v = map[string]interface{}{"type": "array", "items": et}
+		} else {
+			v = map[string]interface{}{"type": "map", "values": et}
+		}
+
+	case "union":
+		if _, err := p.expect('{', "{"); err != nil {
+			return nil, false, err
+		}
+		branches := []interface{}{}
+		for !p.accept('}') {
+			if len(branches) > 0 {
+				if _, err := p.expect(',', ","); err != nil {
+					return nil, false, err
+				}
+			}
+			b, err := p.parseNonNullable()
+			if err != nil {
+				return nil, false, err
+			}
+			branches = append(branches, b)
+		}
+		if len(annotations) > 0 {
+			return nil, false, p.errorf(t, "unions cannot be annotated")
+		}
+		v = branches
+
+	case "record", "error", "enum", "fixed", "protocol", "import", "void":
+		return nil, false, p.errorf(t, "expected type, found %v", t)
+
+	default:
+		if len(annotations) > 0 {
+			return nil, false, p.errorf(t, "references to named types cannot be annotated")
+		}
+		v = idlRef(t.text)
+	}
+
+	// Annotations on a type are attributes of its object form.
+	if len(annotations) > 0 {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			m = map[string]interface{}{"type": v}
+		}
+		for k, a := range annotations {
+			m[k] = a
+		}
+		v = m
+	}
+
+	return v, p.accept('?'), nil
+}
+
+// parseNonNullable parses a type that cannot be marked nullable, such as a
+// union branch.
+func (p *idlParser) parseNonNullable() (interface{}, error) {
+	t := p.peek()
+	v, nullable, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	if nullable {
+		return nil, p.errorf(t, "nullable types are only allowed for fields")
+	}
+	return v, nil
+}
+
+// parseJSON parses a JSON value, as used by defaults and annotations, into the
+// form produced by decoding JSON.
+func (p *idlParser) parseJSON() (interface{}, error) {
+	t := p.next()
+
+	switch t.kind {
+	case idlString:
+		var s string
+		if err := json.Unmarshal([]byte(t.text), &s); err != nil {
+			return nil, p.errorf(t, "invalid string %v", t.text)
+		}
+		return s, nil
+
+	case idlNumber:
+		// Numbers are kept exact as in JSON schemas, unless they are written
+		// in a form JSON does not allow, such as 1. or 01.
+		if json.Valid([]byte(t.text)) {
+			return json.Number(t.text), nil
+		}
+		n, _ := strconv.ParseFloat(t.text, 64)
+		return n, nil
+
+	case idlIdent:
+		switch t.text {
+		case "null":
+			return nil, nil
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+
+	case '[':
+		a := []interface{}{}
+		for !p.accept(']') {
+			if len(a) > 0 {
+				if _, err := p.expect(',', ","); err != nil {
+					return nil, err
+				}
+			}
+			v, err := p.parseJSON()
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		return a, nil
+
+	case '{':
+		m := map[string]interface{}{}
+		for !p.accept('}') {
+			if len(m) > 0 {
+				if _, err := p.expect(',', ","); err != nil {
+					return nil, err
+				}
+			}
+			k, err := p.expect(idlString, "")
+			if err != nil {
+				return nil, err
+			}
+			key, err := p.unquote(k)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(':', ":"); err != nil {
+				return nil, err
+			}
+			v, err := p.parseJSON()
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+		}
+		return m, nil
+	}
+
+	return nil, p.errorf(t, "expected JSON value, found %v", t)
+}
+
+func (p *idlParser) unquote(t idlToken) (string, error) {
+	var s string
+	if err := json.Unmarshal([]byte(t.text), &s); err != nil {
+		return "", p.errorf(t, "invalid string %v", t.text)
+	}
+	return s, nil
+}
+
+// qualify replaces the references in type positions with type names. Short
+// names of types declared in the protocol namespace are qualified with it.
+func (p *idlParser) qualify(v interface{}) interface{} {
+	switch x := v.(type) {
+	case idlRef:
+		name := string(x)
+		if fn := fullname(name, p.namespace); p.declared[fn] {
+			return fn
+		}
+		return name
+
+	case []interface{}:
+		for i, e := range x {
+			x[i] = p.qualify(e)
+		}
+
+	case map[string]interface{}:
+		for _, k := range []string{"type", "items", "values"} {
+			if e, ok := x[k]; ok {
+				x[k] = p.qualify(e)
+			}
+		}
+		if fields, ok := x["fields"].([]interface{}); ok {
+			for _, f := range fields {
+				f := f.(map[string]interface{})
+				f["type"] = p.qualify(f["type"])
+			}
+		}
+	}
+
+	return v
+}
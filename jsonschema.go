@@ -0,0 +1,249 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// jsonSchemaDraft identifies the JSON Schema version written by ToJSONSchema.
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// ToJSONSchema renders the schema as a JSON Schema (draft 7) describing the
+// same values, for documentation and validation tooling such as OpenAPI. The
+// mapping is best effort and cannot be converted back:
+//
+// Records become objects whose fields without a default are required, and
+// enums become strings restricted to the symbols. Arrays and maps become
+// arrays and objects of their items and values, and unions become anyOf their
+// branches, so a nullable type allows null. Ints and longs become integers with
+// their range, floats and doubles numbers, and bytes and fixed become strings,
+// with fixed limited to its size, as in Avro's JSON encoding.
+//
+// Named types are written once under "definitions", keyed by full name, and
+// referred to with $ref, so recursive schemas are supported. Names, docs and
+// defaults become titles, descriptions and defaults, while aliases, sort
+// orders and custom attributes are dropped.
+//
+// Logical types describe the value the way JSON APIs usually carry it rather
+// than its Avro encoding: dates, times and timestamps become strings with the
+// date, time and date-time formats, durations strings with the duration
+// format, decimals numbers and uuids strings with the uuid format. Other
+// logical types are described by the type they annotate.
+//
+// Union values are described unwrapped. Avro's JSON encoding instead wraps a
+// non-null union value in an object keyed by its branch, which this mapping
+// does not describe.
+func ToJSONSchema(s Schema) ([]byte, error) {
+	w := jsonSchemaWriter{
+		defined: make(map[string]int),
+	}
+
+	root, err := w.schema(s, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// A $ref cannot have siblings in draft 7, so it is wrapped in allOf.
+	o := object{{"$schema", jsonSchemaDraft}}
+	if r := root.(object); r.has("$ref") {
+		o = append(o, member{"allOf", []interface{}{r}})
+	} else {
+		o = append(o, r...)
+	}
+	if len(w.definitions) > 0 {
+		o = append(o, member{"definitions", w.definitions})
+	}
+	return json.Marshal(o)
+}
+
+// jsonSchemaWriter translates schemas, collecting the definitions of named
+// types in the order they are first reached.
+type jsonSchemaWriter struct {
+	definitions object
+	defined     map[string]int
+}
+
+// schema translates a schema defined where the namespace is in effect.
+func (w *jsonSchemaWriter) schema(s Schema, namespace string) (interface{}, error) {
+	switch x := s.(type) {
+	case Primitive:
+		return jsonSchemaPrimitive(x)
+
+	case *Record:
+		fn := fullname(x.Name, w.inherit(x.Namespace, namespace))
+		if w.define(fn) {
+			o := object{{"type", "object"}, {"title", x.Name}}
+			if x.Doc != "" {
+				o = append(o, member{"description", x.Doc})
+			}
+
+			properties := make(object, len(x.Fields))
+			var required []string
+			for i, f := range x.Fields {
+				p, err := w.schema(f.Type, namespaceOf(fn))
+				if err != nil {
+					return nil, withPath(f.Name, err)
+				}
+				p = withMembers(p, f.Doc, f.Default, f.HasDefault())
+				properties[i] = member{f.Name, p}
+
+				if !f.HasDefault() {
+					required = append(required, f.Name)
+				}
+			}
+			o = append(o, member{"properties", properties})
+			if len(required) > 0 {
+				o = append(o, member{"required", required})
+			}
+			o = append(o, member{"additionalProperties", false})
+
+			w.definitions[w.defined[fn]].value = o
+		}
+		return jsonSchemaRef(fn), nil
+
+	case *Enum:
+		fn := fullname(x.Name, w.inherit(x.Namespace, namespace))
+		if w.define(fn) {
+			o := object{{"type", "string"}, {"title", x.Name}}
+			if x.Doc != "" {
+				o = append(o, member{"description", x.Doc})
+			}
+			symbols := x.Symbols
+			if symbols == nil {
+				symbols = []string{}
+			}
+			o = append(o, member{"enum", symbols})
+
+			w.definitions[w.defined[fn]].value = o
+		}
+		return jsonSchemaRef(fn), nil
+
+	case *Fixed:
+		fn := fullname(x.Name, w.inherit(x.Namespace, namespace))
+		if w.define(fn) {
+			w.definitions[w.defined[fn]].value = object{
+				{"type", "string"},
+				{"title", x.Name},
+				{"minLength", x.Size},
+				{"maxLength", x.Size},
+			}
+		}
+		return jsonSchemaRef(fn), nil
+
+	case *Array:
+		items, err := w.schema(x.Items, namespace)
+		if err != nil {
+			return nil, err
+		}
+		return object{{"type", "array"}, {"items", items}}, nil
+
+	case *Map:
+		values, err := w.schema(x.Values, namespace)
+		if err != nil {
+			return nil, err
+		}
+		return object{{"type", "object"}, {"additionalProperties", values}}, nil
+
+	case Union:
+		branches := make([]interface{}, len(x))
+		for i, t := range x {
+			b, err := w.schema(t, namespace)
+			if err != nil {
+				return nil, err
+			}
+			branches[i] = b
+		}
+		return object{{"anyOf", branches}}, nil
+
+	case *Reference:
+		if x.Schema == nil {
+			return nil, fmt.Errorf("avroschema: unresolved reference to %v", x.Name)
+		}
+		return w.schema(x.Schema, namespaceOf(x.Name))
+
+	case *Decimal, *annotatedDecimal, *FixedDecimal:
+		return object{{"type", "number"}}, nil
+	}
+
+	switch s.Type() {
+	case Date.Type():
+		return object{{"type", "string"}, {"format", "date"}}, nil
+	case TimeMillis.Type(), TimeMicros.Type():
+		return object{{"type", "string"}, {"format", "time"}}, nil
+	case TimestampMillis.Type(), TimestampMicros.Type():
+		return object{{"type", "string"}, {"format", "date-time"}}, nil
+	case Duration.Type():
+		return object{{"type", "string"}, {"format", "duration"}}, nil
+	case "uuid":
+		return object{{"type", "string"}, {"format", "uuid"}}, nil
+	}
+
+	// Other logical types are described by the type they annotate.
+	if u := Underlying(s); u != s {
+		return w.schema(u, namespace)
+	}
+
+	return nil, fmt.Errorf("avroschema: unsupported schema type %v", s.Type())
+}
+
+// define reserves the definition of a named type, reporting whether it has to
+// be written. Definitions are reserved before they are written so that they
+// are kept in the order they are first reached and recursion ends.
+func (w *jsonSchemaWriter) define(fullname string) bool {
+	if _, ok := w.defined[fullname]; ok {
+		return false
+	}
+	w.defined[fullname] = len(w.definitions)
+	w.definitions = append(w.definitions, member{fullname, nil})
+	return true
+}
+
+func (w *jsonSchemaWriter) inherit(namespace, enclosing string) string {
+	if namespace != "" {
+		return namespace
+	}
+	return enclosing
+}
+
+func jsonSchemaRef(fullname string) object {
+	return object{{"$ref", "#/definitions/" + fullname}}
+}
+
+func jsonSchemaPrimitive(p Primitive) (interface{}, error) {
+	switch p {
+	case Null:
+		return object{{"type", "null"}}, nil
+	case Boolean:
+		return object{{"type", "boolean"}}, nil
+	case Int:
+		return object{{"type", "integer"}, {"minimum", math.MinInt32}, {"maximum", math.MaxInt32}}, nil
+	case Long:
+		return object{{"type", "integer"}, {"minimum", int64(math.MinInt64)}, {"maximum", int64(math.MaxInt64)}}, nil
+	case Float, Double:
+		return object{{"type", "number"}}, nil
+	case Bytes, String:
+		return object{{"type", "string"}}, nil
+	}
+	return nil, fmt.Errorf("avroschema: unsupported schema type %v", p)
+}
+
+// withMembers adds a field's doc and default to the schema of its type,
+// wrapping a $ref in allOf first.
+func withMembers(p interface{}, doc string, def interface{}, hasDefault bool) interface{} {
+	if doc == "" && !hasDefault {
+		return p
+	}
+
+	o := p.(object)
+	if o.has("$ref") {
+		o = object{{"allOf", []interface{}{o}}}
+	}
+	if doc != "" {
+		o = append(o, member{"description", doc})
+	}
+	if hasDefault {
+		o = append(o, member{"default", def})
+	}
+	return o
+}
@@ -0,0 +1,98 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestToJSONSchema(t *testing.T) {
+	s := MustParse(`{
+		"type": "record",
+		"name": "Person",
+		"namespace": "com.example",
+		"doc": "A person.",
+		"fields": [
+			{"name": "name", "type": "string", "doc": "Full name."},
+			{"name": "age", "type": ["null", "int"], "default": null},
+			{"name": "born", "type": {"type": "int", "logicalType": "date"}},
+			{"name": "role", "type": {"type": "enum", "name": "Role", "symbols": ["ADMIN", "USER"]}, "default": "USER"},
+			{"name": "tags", "type": {"type": "map", "values": "string"}},
+			{"name": "friends", "type": {"type": "array", "items": "Person"}}
+		]
+	}`)
+
+	exp := `{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"allOf": [{"$ref": "#/definitions/com.example.Person"}],
+		"definitions": {
+			"com.example.Person": {
+				"type": "object",
+				"title": "Person",
+				"description": "A person.",
+				"properties": {
+					"name": {"type": "string", "description": "Full name."},
+					"age": {
+						"anyOf": [
+							{"type": "null"},
+							{"type": "integer", "minimum": -2147483648, "maximum": 2147483647}
+						],
+						"default": null
+					},
+					"born": {"type": "string", "format": "date"},
+					"role": {"allOf": [{"$ref": "#/definitions/com.example.Role"}], "default": "USER"},
+					"tags": {"type": "object", "additionalProperties": {"type": "string"}},
+					"friends": {"type": "array", "items": {"$ref": "#/definitions/com.example.Person"}}
+				},
+				"required": ["name", "born", "tags", "friends"],
+				"additionalProperties": false
+			},
+			"com.example.Role": {"type": "string", "title": "Role", "enum": ["ADMIN", "USER"]}
+		}
+	}`
+
+	b, err := ToJSONSchema(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(exp)); err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != buf.String() {
+		t.Errorf("expected %s, got %s", buf.Bytes(), b)
+	}
+}
+
+func TestToJSONSchemaTypes(t *testing.T) {
+	tests := []struct {
+		Schema Schema
+		JSON   string
+	}{
+		{Boolean, `{"type":"boolean"}`},
+		{Long, `{"type":"integer","minimum":-9223372036854775808,"maximum":9223372036854775807}`},
+		{Double, `{"type":"number"}`},
+		{Bytes, `{"type":"string"}`},
+		{TimestampMillis, `{"type":"string","format":"date-time"}`},
+		{&Decimal{Precision: 9, Scale: 2}, `{"type":"number"}`},
+		{&Array{Items: &Fixed{Name: "MD5", Size: 16}}, `{"type":"array","items":{"$ref":"#/definitions/MD5"},` +
+			`"definitions":{"MD5":{"type":"string","title":"MD5","minLength":16,"maxLength":16}}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Schema.Type(), func(t *testing.T) {
+			b, err := ToJSONSchema(test.Schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			exp := `{"$schema":"http://json-schema.org/draft-07/schema#",` + test.JSON[1:]
+			if string(b) != exp {
+				t.Errorf("expected %s, got %s", exp, b)
+			}
+		})
+	}
+
+	_, err := ToJSONSchema(&Reference{Name: "Missing"})
+	checkError(t, err, "unresolved reference to Missing")
+}
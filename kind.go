@@ -0,0 +1,230 @@
+package avro
+
+// Kind identifies the kind of a schema without comparing type names.
+type Kind int
+
+const (
+	// KindInvalid is the kind of an unresolved reference or a schema type
+	// this package does not know.
+	KindInvalid Kind = iota
+	KindNull
+	KindBoolean
+	KindInt
+	KindLong
+	KindFloat
+	KindDouble
+	KindBytes
+	KindString
+	KindRecord
+	KindEnum
+	KindArray
+	KindMap
+	KindUnion
+	KindFixed
+	// KindLogical is the kind of all logical types, which LogicalKindOf
+	// tells apart. The schema's Type names the logical type and Underlying
+	// returns the type it annotates.
+	KindLogical
+)
+
+var kindNames = [...]string{
+	KindInvalid: "invalid",
+	KindNull:    "null",
+	KindBoolean: "boolean",
+	KindInt:     "int",
+	KindLong:    "long",
+	KindFloat:   "float",
+	KindDouble:  "double",
+	KindBytes:   "bytes",
+	KindString:  "string",
+	KindRecord:  "record",
+	KindEnum:    "enum",
+	KindArray:   "array",
+	KindMap:     "map",
+	KindUnion:   "union",
+	KindFixed:   "fixed",
+	KindLogical: "logical",
+}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return kindNames[KindInvalid]
+	}
+	return kindNames[k]
+}
+
+// LogicalKind identifies a logical type, the sub-kind of KindLogical.
+type LogicalKind int
+
+const (
+	// LogicalNone is the logical kind of schemas that are not logical types.
+	LogicalNone LogicalKind = iota
+	LogicalDecimal
+	LogicalDate
+	LogicalTimeMillis
+	LogicalTimeMicros
+	LogicalTimestampMillis
+	LogicalTimestampMicros
+	LogicalDuration
+	// LogicalOther is the logical kind of logical types the spec does not
+	// define, registered or kept as UnknownLogical. The schema's Type names
+	// the logical type.
+	LogicalOther
+)
+
+var logicalKindNames = [...]string{
+	LogicalNone:            "none",
+	LogicalDecimal:         "decimal",
+	LogicalDate:            "date",
+	LogicalTimeMillis:      "time-millis",
+	LogicalTimeMicros:      "time-micros",
+	LogicalTimestampMillis: "timestamp-millis",
+	LogicalTimestampMicros: "timestamp-micros",
+	LogicalDuration:        "duration",
+	LogicalOther:           "other",
+}
+
+func (k LogicalKind) String() string {
+	if k < 0 || int(k) >= len(logicalKindNames) {
+		return logicalKindNames[LogicalNone]
+	}
+	return logicalKindNames[k]
+}
+
+// LogicalKindOf returns the logical kind of a schema whose kind is
+// KindLogical, following references, and LogicalNone for other schemas.
+func LogicalKindOf(s Schema) LogicalKind {
+	switch x := s.(type) {
+	case *Decimal, *annotatedDecimal, *FixedDecimal:
+		return LogicalDecimal
+	case *date:
+		return LogicalDate
+	case *timeMillis:
+		return LogicalTimeMillis
+	case *timeMicros:
+		return LogicalTimeMicros
+	case *timestampMillis:
+		return LogicalTimestampMillis
+	case *timestampMicros:
+		return LogicalTimestampMicros
+	case *duration:
+		return LogicalDuration
+	case *Reference:
+		if x.Schema == nil {
+			return LogicalNone
+		}
+		return LogicalKindOf(x.Schema)
+	}
+
+	if KindOf(s) == KindLogical {
+		return LogicalOther
+	}
+	return LogicalNone
+}
+
+// KindOf returns the kind of any schema, including custom logical types that
+// do not have a Kind method.
+func KindOf(s Schema) Kind {
+	if k, ok := s.(interface{ Kind() Kind }); ok {
+		return k.Kind()
+	}
+	if u := Underlying(s); u != s {
+		return KindLogical
+	}
+	return KindInvalid
+}
+
+func (p Primitive) Kind() Kind {
+	switch p {
+	case Null:
+		return KindNull
+	case Boolean:
+		return KindBoolean
+	case Int:
+		return KindInt
+	case Long:
+		return KindLong
+	case Float:
+		return KindFloat
+	case Double:
+		return KindDouble
+	case Bytes:
+		return KindBytes
+	case String:
+		return KindString
+	}
+	return KindInvalid
+}
+
+func (r *Record) Kind() Kind {
+	return KindRecord
+}
+
+func (e *Enum) Kind() Kind {
+	return KindEnum
+}
+
+func (a *Array) Kind() Kind {
+	return KindArray
+}
+
+func (m *Map) Kind() Kind {
+	return KindMap
+}
+
+func (u Union) Kind() Kind {
+	return KindUnion
+}
+
+func (f *Fixed) Kind() Kind {
+	return KindFixed
+}
+
+// Kind returns the kind of the referenced type, or KindInvalid if the
+// reference is not resolved.
+func (r *Reference) Kind() Kind {
+	if r.Schema == nil {
+		return KindInvalid
+	}
+	return KindOf(r.Schema)
+}
+
+func (d *Decimal) Kind() Kind {
+	return KindLogical
+}
+
+func (d *FixedDecimal) Kind() Kind {
+	return KindLogical
+}
+
+func (d *date) Kind() Kind {
+	return KindLogical
+}
+
+func (t *timeMillis) Kind() Kind {
+	return KindLogical
+}
+
+func (t *timeMicros) Kind() Kind {
+	return KindLogical
+}
+
+func (t *timestampMillis) Kind() Kind {
+	return KindLogical
+}
+
+func (t *timestampMicros) Kind() Kind {
+	return KindLogical
+}
+
+func (d *duration) Kind() Kind {
+	return KindLogical
+}
+
+func (u *UnknownLogical) Kind() Kind {
+	return KindLogical
+}
+
+func (a *AnnotatedPrimitive) Kind() Kind {
+	return a.Primitive.Kind()
+}
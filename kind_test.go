@@ -0,0 +1,81 @@
+package avro
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestKind(t *testing.T) {
+	node := &Record{Name: "Node"}
+
+	tests := []struct {
+		Schema Schema
+		Kind   Kind
+	}{
+		{Null, KindNull},
+		{Boolean, KindBoolean},
+		{Int, KindInt},
+		{Long, KindLong},
+		{Float, KindFloat},
+		{Double, KindDouble},
+		{Bytes, KindBytes},
+		{String, KindString},
+		{Primitive("uint"), KindInvalid},
+		{node, KindRecord},
+		{&Enum{Name: "E", Symbols: []string{"A"}}, KindEnum},
+		{&Array{Items: Int}, KindArray},
+		{&Map{Values: Int}, KindMap},
+		{Union{Null, Int}, KindUnion},
+		{&Fixed{Name: "F", Size: 4}, KindFixed},
+		{&Reference{Name: "Node", Schema: node}, KindRecord},
+		{&Reference{Name: "Node"}, KindInvalid},
+		{&Decimal{Precision: 4, Scale: 2}, KindLogical},
+		{Date, KindLogical},
+		{TimeMillis, KindLogical},
+		{TimeMicros, KindLogical},
+		{TimestampMillis, KindLogical},
+		{TimestampMicros, KindLogical},
+		{Duration, KindLogical},
+		{&uuid{}, KindLogical},
+		{&UnknownLogical{Name: "made-up", Backing: String}, KindLogical},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			if k := KindOf(test.Schema); k != test.Kind {
+				t.Errorf("expected %v, got %v", test.Kind, k)
+			}
+		})
+	}
+}
+
+func TestLogicalKind(t *testing.T) {
+	tests := []struct {
+		Schema Schema
+		Kind   LogicalKind
+	}{
+		{Int, LogicalNone},
+		{&Fixed{Name: "F", Size: 12}, LogicalNone},
+		{&Decimal{Precision: 4, Scale: 2}, LogicalDecimal},
+		{&FixedDecimal{Precision: 4, Scale: 2, Fixed: &Fixed{Name: "money", Size: 4}}, LogicalDecimal},
+		{MustParse(`{"type":"bytes","logicalType":"decimal","precision":4,"scale":2,"x":1}`), LogicalDecimal},
+		{Date, LogicalDate},
+		{TimeMillis, LogicalTimeMillis},
+		{TimeMicros, LogicalTimeMicros},
+		{TimestampMillis, LogicalTimestampMillis},
+		{TimestampMicros, LogicalTimestampMicros},
+		{Duration, LogicalDuration},
+		{&Reference{Name: "duration", Schema: Duration}, LogicalDuration},
+		{&Reference{Name: "duration"}, LogicalNone},
+		{&uuid{}, LogicalOther},
+		{&UnknownLogical{Name: "made-up", Backing: String}, LogicalOther},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			if k := LogicalKindOf(test.Schema); k != test.Kind {
+				t.Errorf("expected %v, got %v", test.Kind, k)
+			}
+		})
+	}
+}
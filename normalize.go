@@ -0,0 +1,254 @@
+package avro
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Normalize returns a fully resolved copy of the schema, ready to be handed to
+// a codec, or an error if the schema is not valid. In the copy, every named
+// type has its short name and the namespace it is in, whether it was written
+// with a full name or inherited its namespace. Each named type is defined once,
+// where it is first reached, and referred to by a Reference thereafter, even if
+// the schema shared it by pointer. Every Reference is resolved, either to the
+// type it already referred to or to the named type of the same full name
+// defined elsewhere in the schema, and the copy is validated.
+//
+// A recursive schema produces a cyclic graph, since the Schema of a Reference
+// may be the record containing it. Code walking the result must stop at
+// references or track the records it has visited, as Equal does.
+func Normalize(s Schema) (Schema, error) {
+	q := qualifier{
+		copies: make(map[Schema]Schema),
+		names:  make(map[string]Schema),
+	}
+	if err := collectNames(s, q.names); err != nil {
+		return nil, err
+	}
+
+	n := q.qualify(s, "")
+	if name := unresolved(n, make(map[*Record]bool)); name != "" {
+		return nil, fmt.Errorf("avroschema: unknown type %v", name)
+	}
+	if err := Validate(n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// EqualNormalized is like Equal but compares named types by their full names
+// rather than by the names and namespaces as they are written. A named type
+// without a namespace inherits the one in effect where it is defined, and a
+// name containing a dot sets its own namespace, so a nested record relying on
+// inheritance is equal to one spelling out the same namespace.
+func EqualNormalized(s1, s2 Schema) bool {
+	q1 := qualifier{copies: make(map[Schema]Schema)}
+	q2 := qualifier{copies: make(map[Schema]Schema)}
+	return Equal(q1.qualify(s1, ""), q2.qualify(s2, ""))
+}
+
+// qualifier copies a schema, qualifying the names of its named types.
+type qualifier struct {
+	// copies maps the named types already copied to their copy, so that
+	// shared and recursive types are copied once.
+	copies map[Schema]Schema
+
+	// names holds named types by full name to resolve references that have
+	// no schema. It may be nil.
+	names map[string]Schema
+}
+
+// qualify returns a copy of the schema in which every named type has its short
+// name and its resolved namespace. The enclosing namespace is the one in effect
+// where the schema is defined. A named type that has already been copied is
+// replaced by a reference to its copy.
+func (q *qualifier) qualify(s Schema, enclosing string) Schema {
+	switch s.(type) {
+	case *Record, *Enum, *Fixed:
+		if c, ok := q.copies[s]; ok {
+			return &Reference{Name: c.(Named).Fullname(), Schema: c}
+		}
+	}
+
+	switch x := s.(type) {
+	case *Record:
+		name, namespace := splitName(x.Name, x.Namespace, enclosing)
+		r := &Record{
+			Name:      name,
+			Namespace: namespace,
+			Doc:       x.Doc,
+			Aliases:   x.Aliases,
+			Error:     x.Error,
+			Props:     x.Props,
+		}
+		q.copies[s] = r
+
+		r.Fields = make([]*Field, len(x.Fields))
+		for i, f := range x.Fields {
+			qf := *f
+			qf.Type = q.qualify(f.Type, namespace)
+			r.Fields[i] = &qf
+		}
+		return r
+
+	case *Enum:
+		name, namespace := splitName(x.Name, x.Namespace, enclosing)
+		e := &Enum{
+			Name:      name,
+			Namespace: namespace,
+			Doc:       x.Doc,
+			Aliases:   x.Aliases,
+			Symbols:   x.Symbols,
+			Default:   x.Default,
+			Props:     x.Props,
+		}
+		q.copies[s] = e
+		return e
+
+	case *Fixed:
+		name, namespace := splitName(x.Name, x.Namespace, enclosing)
+		f := &Fixed{
+			Name:      name,
+			Namespace: namespace,
+			Size:      x.Size,
+			Aliases:   x.Aliases,
+			Props:     x.Props,
+		}
+		q.copies[s] = f
+		return f
+
+	case *Array:
+		a := *x
+		a.Items = q.qualify(x.Items, enclosing)
+		return &a
+
+	case *Map:
+		m := *x
+		m.Values = q.qualify(x.Values, enclosing)
+		return &m
+
+	case Union:
+		u := make(Union, len(x))
+		for i, t := range x {
+			u[i] = q.qualify(t, enclosing)
+		}
+		return u
+
+	case *Reference:
+		fn, target := fullname(x.Name, enclosing), x.Schema
+		if target == nil {
+			fn, target = q.lookup(x.Name, enclosing)
+		}
+		if target == nil {
+			return &Reference{Name: fn}
+		}
+
+		// The referenced type is copied here if it has not been reached yet.
+		c := q.qualify(target, namespaceOf(fn))
+		if r, ok := c.(*Reference); ok {
+			return r
+		}
+		if n, ok := c.(Named); ok {
+			fn = n.Fullname()
+		}
+		return &Reference{Name: fn, Schema: c}
+
+	case *FixedDecimal:
+		d := *x
+		if f, ok := q.qualify(x.Fixed, enclosing).(*Fixed); ok {
+			d.Fixed = f
+		}
+		return &d
+
+	case *duration:
+		if x.Fixed == nil {
+			return x
+		}
+		d := *x
+		if f, ok := q.qualify(x.Fixed, enclosing).(*Fixed); ok {
+			d.Fixed = f
+		}
+		return &d
+	}
+
+	return s
+}
+
+// lookup resolves the name of a reference that has no schema as the parser
+// does, returning its full name and the named type, or nil if it is unknown.
+func (q *qualifier) lookup(name, enclosing string) (string, Schema) {
+	fn := fullname(name, enclosing)
+	if s, ok := q.names[fn]; ok {
+		return fn, s
+	}
+	if s, ok := q.names[name]; ok {
+		return name, s
+	}
+	return fn, nil
+}
+
+// collectNames adds the named types defined within the schema to names, keyed
+// by full name. An error is returned if two different types share a name.
+func collectNames(s Schema, names map[string]Schema) error {
+	var types []namedType
+	collectTypes(s, "", make(map[Schema]bool), &types)
+
+	for _, t := range types {
+		if d, ok := names[t.fullname]; ok && d != t.schema {
+			return fmt.Errorf("avroschema: type %v is defined more than once", t.fullname)
+		}
+		names[t.fullname] = t.schema
+	}
+	return nil
+}
+
+// unresolved returns the name of the first reference that has no schema, or an
+// empty string if every reference is resolved.
+func unresolved(s Schema, seen map[*Record]bool) string {
+	switch x := s.(type) {
+	case *Record:
+		if seen[x] {
+			return ""
+		}
+		seen[x] = true
+		for _, f := range x.Fields {
+			if name := unresolved(f.Type, seen); name != "" {
+				return name
+			}
+		}
+
+	case *Array:
+		return unresolved(x.Items, seen)
+
+	case *Map:
+		return unresolved(x.Values, seen)
+
+	case Union:
+		for _, t := range x {
+			if name := unresolved(t, seen); name != "" {
+				return name
+			}
+		}
+
+	case *Reference:
+		if x.Schema == nil {
+			return x.Name
+		}
+		return unresolved(x.Schema, seen)
+	}
+
+	return ""
+}
+
+// splitName returns the short name and namespace of a named type defined where
+// the enclosing namespace is in effect.
+func splitName(name, namespace, enclosing string) (string, string) {
+	if namespace == "" {
+		namespace = enclosing
+	}
+	fn := fullname(name, namespace)
+	if i := strings.LastIndex(fn, "."); i >= 0 {
+		return fn[i+1:], fn[:i]
+	}
+	return fn, ""
+}
@@ -0,0 +1,150 @@
+package avro
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEqualNormalized(t *testing.T) {
+	// The address record relies on inheriting the namespace of the person
+	// record in one schema and spells it out in the other.
+	newPerson := func(namespace string) *Record {
+		return &Record{
+			Name:      "Person",
+			Namespace: "com.x",
+			Fields: []*Field{
+				{Name: "address", Type: &Record{
+					Name:      "Address",
+					Namespace: namespace,
+					Fields:    []*Field{{Name: "city", Type: String}},
+				}},
+			},
+		}
+	}
+
+	tests := []struct {
+		A, B  Schema
+		Equal bool
+	}{
+		{newPerson(""), newPerson("com.x"), true},
+		{newPerson(""), newPerson("com.y"), false},
+		{&Fixed{Name: "com.x.Hash", Size: 16}, &Fixed{Name: "Hash", Namespace: "com.x", Size: 16}, true},
+		{&Fixed{Name: "Hash", Size: 16}, &Fixed{Name: "Hash", Namespace: "com.x", Size: 16}, false},
+		{
+			&Array{Items: &Enum{Name: "Suit", Namespace: "cards", Symbols: []string{"SPADES"}}},
+			&Array{Items: &Enum{Name: "cards.Suit", Symbols: []string{"SPADES"}}},
+			true,
+		},
+		{
+			Union{Null, &Fixed{Name: "MD5", Namespace: "com.x", Size: 16}},
+			Union{Null, &Fixed{Name: "com.x.MD5", Size: 16}},
+			true,
+		},
+		{newTree(true), newTree(true), true},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			if eq := EqualNormalized(test.A, test.B); eq != test.Equal {
+				t.Errorf("expected %v, got %v", test.Equal, eq)
+			}
+			if eq := EqualNormalized(test.B, test.A); eq != test.Equal {
+				t.Errorf("expected %v swapped, got %v", test.Equal, eq)
+			}
+		})
+	}
+
+	// Equal compares the namespaces as written.
+	if Equal(newPerson(""), newPerson("com.x")) {
+		t.Errorf("expected Equal to compare namespaces literally")
+	}
+}
+
+func TestEqualNormalizedParsed(t *testing.T) {
+	a := MustParse(`{"type":"record","name":"Person","namespace":"com.x","fields":[` +
+		`{"name":"address","type":{"type":"record","name":"Address","fields":[{"name":"city","type":"string"}]}}]}`)
+	b := MustParse(`{"type":"record","name":"Person","namespace":"com.x","fields":[` +
+		`{"name":"address","type":{"type":"record","name":"Address","namespace":"com.x","fields":[{"name":"city","type":"string"}]}}]}`)
+
+	if !EqualNormalized(a, b) {
+		t.Errorf("expected %v and %v to be equal", a, b)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	address := &Record{
+		Name:   "Address",
+		Fields: []*Field{{Name: "city", Type: String}},
+	}
+	person := &Record{
+		Name:      "Person",
+		Namespace: "com.x",
+		Fields: []*Field{
+			{Name: "home", Type: address},
+			{Name: "work", Type: address},
+			{Name: "previous", Type: &Array{Items: &Reference{Name: "Address"}}},
+			{Name: "friends"},
+		},
+	}
+	person.Fields[3].Type = &Array{Items: person}
+
+	s, err := Normalize(person)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The shared and recursive types are defined once and the unresolved
+	// reference is resolved, so the result marshals to a valid schema.
+	b, err := Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{"type":"record","name":"Person","namespace":"com.x","fields":[` +
+		`{"name":"home","type":{"type":"record","name":"Address","namespace":"com.x","fields":[{"name":"city","type":"string"}]}},` +
+		`{"name":"work","type":"com.x.Address"},` +
+		`{"name":"previous","type":{"type":"array","items":"com.x.Address"}},` +
+		`{"name":"friends","type":{"type":"array","items":"com.x.Person"}}]}`
+	if string(b) != exp {
+		t.Errorf("expected %s, got %s", exp, b)
+	}
+
+	r := s.(*Record)
+	if ref := r.Fields[3].Type.(*Array).Items.(*Reference); ref.Schema != s {
+		t.Errorf("expected the reference to be the normalized record, got %v", ref.Schema)
+	}
+	if ref := r.Fields[2].Type.(*Array).Items.(*Reference); ref.Schema != r.Fields[0].Type {
+		t.Errorf("expected the reference to be the normalized address, got %v", ref.Schema)
+	}
+
+	// The input is not modified.
+	if address.Namespace != "" {
+		t.Errorf("expected the input to be kept, got namespace %q", address.Namespace)
+	}
+}
+
+func TestNormalizeErrors(t *testing.T) {
+	tests := []struct {
+		Schema Schema
+		Error  string
+	}{
+		{
+			&Array{Items: &Reference{Name: "Missing"}},
+			"unknown type Missing",
+		},
+		{
+			Union{&Fixed{Name: "F", Size: 1}, &Fixed{Name: "F", Size: 2}},
+			"type F is defined more than once",
+		},
+		{
+			&Record{Name: "R", Fields: []*Field{{Name: "e", Type: &Enum{Name: "E", Symbols: []string{"A", "A"}}}}},
+			`duplicate symbol "A"`,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			_, err := Normalize(test.Schema)
+			checkError(t, err, test.Error)
+		})
+	}
+}
@@ -0,0 +1,823 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Parser unmarshals encoded schemas with configurable behavior. The zero value
+// is ready to use and behaves like Unmarshal.
+type Parser struct {
+	// StrictLogicalTypes causes a logical type that annotates a type other
+	// than the one it requires, such as a string-based date, to be an error.
+	// By default the annotation is ignored and the underlying type is used,
+	// as the spec requires.
+	StrictLogicalTypes bool
+
+	// DisallowUnknownFields causes attributes the spec does not define for a
+	// type or field, such as a misspelled "fields", to be an error rather than
+	// being kept as custom attributes in Props. Objects with a logicalType are
+	// exempt since logical types may define their own attributes.
+	DisallowUnknownFields bool
+
+	// KeepUnknownLogicalTypes causes a logical type that is neither built in
+	// nor registered to be kept as an UnknownLogical when it annotates a
+	// primitive, so that it survives a round trip. By default only the
+	// primitive is kept. Complex types always keep the annotation in Props.
+	KeepUnknownLogicalTypes bool
+
+	// MaxFixedSize limits the size of fixed types. A decoder allocates the
+	// size of a fixed for each value, so an untrusted schema could otherwise
+	// request huge allocations. Zero means DefaultMaxFixedSize and a negative
+	// value disables the limit. Negative sizes are always an error.
+	MaxFixedSize int
+
+	// Names holds named types defined outside the schema being parsed, such
+	// as those loaded by ParseFiles, keyed by full name. References to them
+	// resolve to these schemas, and a schema that is just the name of one
+	// parses as the named type itself. Types defined by the schema take
+	// precedence over these.
+	Names map[string]Schema
+
+	// AllowComments accepts hand-edited schema files that contain // line
+	// comments and trailing commas in arrays and objects, which JSON does
+	// not allow. Both are removed before the JSON is decoded, so comments
+	// are not kept in the schema, its marshaled form or its canonical form.
+	AllowComments bool
+}
+
+// DefaultMaxFixedSize is the largest fixed size a Parser accepts unless its
+// MaxFixedSize is set.
+const DefaultMaxFixedSize = 1 << 20
+
+// Parse unmarshals an encoded schema into a schema value.
+func (p *Parser) Parse(b []byte) (Schema, error) {
+	if p.AllowComments {
+		b = stripComments(b)
+	}
+	b = bytes.TrimSpace(b)
+
+	// Nothing to do.
+	if len(b) == 0 {
+		return nil, nil
+	}
+
+	var v interface{}
+	if err := decodeJSON(b, &v); err != nil {
+		return nil, err
+	}
+
+	return newParser(p).parseTop(v)
+}
+
+// ParseValue builds a schema from a value that has already been decoded from
+// JSON into an interface{}: a string, []interface{} or map[string]interface{},
+// dispatched on as Parse dispatches on the JSON. A json.RawMessage is parsed
+// as with Parse.
+func (p *Parser) ParseValue(v interface{}) (Schema, error) {
+	if b, ok := v.(json.RawMessage); ok {
+		return p.Parse(b)
+	}
+	return newParser(p).parseTop(v)
+}
+
+// FromMap builds a schema from a schema object that has already been decoded
+// from JSON, such as one embedded in a larger document. The map and its members
+// must hold the types produced by decoding JSON into an interface{} value.
+func FromMap(m map[string]interface{}) (Schema, error) {
+	return newParser(&Parser{}).parseObject(m, "")
+}
+
+// parser holds the state needed while decoding a single schema document,
+// namely the named types defined so far so that later references to them
+// can be resolved.
+type parser struct {
+	opts  *Parser
+	names map[string]Schema
+}
+
+func newParser(opts *Parser) *parser {
+	return &parser{
+		opts:  opts,
+		names: make(map[string]Schema),
+	}
+}
+
+// parseTop decodes a whole schema document. A document that is only the name
+// of a named type is that type rather than a reference to it.
+func (p *parser) parseTop(v interface{}) (Schema, error) {
+	s, err := p.parse(v, "")
+	if e, ok := err.(*unknownTypeError); ok {
+		return nil, fmt.Errorf("avroschema: %v is neither a primitive type nor a known named type", e.name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r, ok := s.(*Reference); ok {
+		return r.Schema, nil
+	}
+	return s, nil
+}
+
+// parse decodes a schema from its JSON-decoded value. The namespace is the
+// enclosing namespace used to resolve names that are not fully qualified.
+func (p *parser) parse(v interface{}, namespace string) (Schema, error) {
+	switch x := v.(type) {
+	// String-based type, so this is a primitive or a reference to a named type.
+	case string:
+		if IsPrimitive(x) {
+			return Primitive(x), nil
+		}
+
+		return p.lookup(x, namespace)
+
+		// Array implies a union.
+	case []interface{}:
+		var u Union
+		if err := p.parseUnion(x, namespace, &u); err != nil {
+			return nil, err
+		}
+
+		return u, nil
+
+		// Object implies a complex or logical type.
+	case map[string]interface{}:
+		return p.parseObject(x, namespace)
+	}
+
+	return nil, fmt.Errorf("avroschema: could not unmarshal %v as Schema", v)
+}
+
+// parseObject decodes a schema object.
+func (p *parser) parseObject(m map[string]interface{}, namespace string) (Schema, error) {
+	typ, err := attrString(m, "type")
+	if err != nil {
+		return nil, err
+	}
+
+	logicalType, err := attrString(m, "logicalType")
+	if err != nil {
+		return nil, err
+	}
+
+	// Check for logical types.
+	if logicalType != "" {
+		return p.parseLogical(m, typ, logicalType, namespace)
+	}
+
+	return p.parseType(m, typ, namespace)
+}
+
+// parseType decodes a schema object of the given type, ignoring any logical type.
+func (p *parser) parseType(m map[string]interface{}, typ, namespace string) (Schema, error) {
+	// Check for a primitive type written in its object form, e.g. {"type": "int"}.
+	// A logical type that is ignored is not kept as a custom attribute.
+	if IsPrimitive(typ) {
+		x, err := p.props(m, typ, "type", "logicalType")
+		if err != nil {
+			return nil, err
+		}
+		if x != nil {
+			return &AnnotatedPrimitive{Primitive: Primitive(typ), Props: x}, nil
+		}
+		return Primitive(typ), nil
+	}
+
+	// Check for complex type.
+	switch typ {
+	case "record", "error":
+		r := &Record{Error: typ == "error"}
+		if err := p.parseRecord(m, namespace, r); err != nil {
+			return nil, err
+		}
+		return r, nil
+
+	case "enum":
+		e := &Enum{}
+		if err := p.parseEnum(m, namespace, e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "array":
+		a := &Array{}
+		if err := p.parseArray(m, namespace, a); err != nil {
+			return nil, err
+		}
+		return a, nil
+
+	case "map":
+		x := &Map{}
+		if err := p.parseMap(m, namespace, x); err != nil {
+			return nil, err
+		}
+		return x, nil
+
+	case "fixed":
+		f := &Fixed{}
+		if err := p.parseFixed(m, namespace, f); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+
+	return nil, fmt.Errorf("avroschema: unknown complex type %v", typ)
+}
+
+// logicalTypes maps each logical type to the type it annotates.
+var logicalTypes = map[string]string{
+	"date":             "int",
+	"time-millis":      "int",
+	"time-micros":      "long",
+	"timestamp-millis": "long",
+	"timestamp-micros": "long",
+	"duration":         "fixed",
+	"decimal":          "bytes",
+}
+
+// LogicalTypeFactory creates a custom logical type from the schema it annotates
+// and the remaining attributes of the schema object.
+type LogicalTypeFactory func(backing Schema, attrs map[string]interface{}) (Schema, error)
+
+var (
+	customLogicalTypesMu sync.RWMutex
+	customLogicalTypes   = make(map[string]LogicalTypeFactory)
+)
+
+// RegisterLogicalType makes a custom logical type known to the parser. Logical
+// types that are not registered are decoded as the type they annotate, see
+// Parser.KeepUnknownLogicalTypes. Custom
+// logical types may implement an Underlying() Schema method to be supported by
+// Underlying. Equal compares those by name and the type they annotate, and
+// Marshal writes them with their MarshalJSON method if they have one, or else
+// as the type they annotate with their name as the logicalType. It panics if
+// the name is already registered or is built in.
+func RegisterLogicalType(name string, factory LogicalTypeFactory) {
+	customLogicalTypesMu.Lock()
+	defer customLogicalTypesMu.Unlock()
+
+	if factory == nil {
+		panic("avroschema: RegisterLogicalType factory is nil")
+	}
+	if _, ok := logicalTypes[name]; ok {
+		panic("avroschema: RegisterLogicalType called for built-in logical type " + name)
+	}
+	if _, ok := customLogicalTypes[name]; ok {
+		panic("avroschema: RegisterLogicalType called twice for logical type " + name)
+	}
+
+	customLogicalTypes[name] = factory
+}
+
+// parseLogical decodes a logical type. If the logical type is unknown or does
+// not annotate the type it requires, the underlying type is decoded instead.
+func (p *parser) parseLogical(m map[string]interface{}, typ, logicalType, namespace string) (Schema, error) {
+	backing, ok := logicalTypes[logicalType]
+	if !ok {
+		return p.parseCustomLogical(m, typ, logicalType, namespace)
+	}
+
+	// Decimals may also annotate fixed types.
+	if typ != backing && !(logicalType == "decimal" && typ == "fixed") {
+		if p.opts.StrictLogicalTypes {
+			return nil, fmt.Errorf("avroschema: logical type %v must annotate %v, not %v", logicalType, backing, typ)
+		}
+		return p.parseType(m, typ, namespace)
+	}
+
+	if logicalType == "duration" {
+		return p.parseDuration(m, namespace)
+	}
+
+	// Other attributes are kept as custom attributes. The predefined
+	// instances are used for the types that have none.
+	x := props(m, "type", "logicalType")
+
+	switch logicalType {
+	case "date":
+		if x == nil {
+			return Date, nil
+		}
+		return &date{Props: x}, nil
+	case "time-millis":
+		if x == nil {
+			return TimeMillis, nil
+		}
+		return &timeMillis{Props: x}, nil
+	case "time-micros":
+		if x == nil {
+			return TimeMicros, nil
+		}
+		return &timeMicros{Props: x}, nil
+	case "timestamp-millis":
+		if x == nil {
+			return TimestampMillis, nil
+		}
+		return &timestampMillis{Props: x}, nil
+	case "timestamp-micros":
+		if x == nil {
+			return TimestampMicros, nil
+		}
+		return &timestampMicros{Props: x}, nil
+	}
+
+	// Decimal is the only parameterized logical type.
+	precision, err := attrInt(m, "precision")
+	if err != nil {
+		return nil, err
+	}
+
+	scale, err := attrInt(m, "scale")
+	if err != nil {
+		return nil, err
+	}
+
+	if typ == "bytes" {
+		d := &Decimal{Precision: precision, Scale: scale}
+
+		// An invalid decimal is ignored in favor of its underlying type.
+		if err := validateDecimal(d); err != nil {
+			if p.opts.StrictLogicalTypes {
+				return nil, err
+			}
+			return Bytes, nil
+		}
+
+		if x := props(m, "type", "logicalType", "precision", "scale"); x != nil {
+			return &annotatedDecimal{Decimal: *d, Props: x}, nil
+		}
+		return d, nil
+	}
+
+	d := &FixedDecimal{Precision: precision, Scale: scale, Fixed: &Fixed{}}
+	if err := p.parseFixed(m, namespace, d.Fixed); err != nil {
+		return nil, err
+	}
+
+	if err := validateFixedDecimal(d); err != nil {
+		if p.opts.StrictLogicalTypes {
+			return nil, err
+		}
+		return d.Fixed, nil
+	}
+
+	// The decimal's attributes are not custom attributes of the fixed type,
+	// and references to the fixed type refer to the decimal.
+	f := d.Fixed
+	f.Props = props(f.Props, "logicalType", "precision", "scale")
+	p.names[fullname(f.Name, f.Namespace)] = d
+
+	return d, nil
+}
+
+// parseDuration decodes a duration, which annotates a fixed type of size 12.
+// The fixed type is defined as usual, but references to it refer to the
+// duration. A duration of another size is ignored in favor of the fixed type.
+func (p *parser) parseDuration(m map[string]interface{}, namespace string) (Schema, error) {
+	f := &Fixed{}
+	if err := p.parseFixed(m, namespace, f); err != nil {
+		return nil, err
+	}
+
+	d := &duration{Fixed: f}
+	if err := validateDuration(d); err != nil {
+		if p.opts.StrictLogicalTypes {
+			return nil, err
+		}
+		return f, nil
+	}
+
+	f.Props = props(f.Props, "logicalType")
+	p.names[fullname(f.Name, f.Namespace)] = d
+
+	return d, nil
+}
+
+func (p *parser) parseCustomLogical(m map[string]interface{}, typ, logicalType, namespace string) (Schema, error) {
+	customLogicalTypesMu.RLock()
+	factory, ok := customLogicalTypes[logicalType]
+	customLogicalTypesMu.RUnlock()
+
+	backing, err := p.parseType(m, typ, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// The attributes of a primitive are the logical type's, so they are not
+	// kept on the primitive.
+	prim, isPrim := backing.(Primitive)
+	if a, ok := backing.(*AnnotatedPrimitive); ok {
+		prim, isPrim = a.Primitive, true
+	}
+
+	if !ok {
+		if isPrim && p.opts.KeepUnknownLogicalTypes {
+			return &UnknownLogical{
+				Name:    logicalType,
+				Backing: prim,
+				Props:   props(m, "type", "logicalType"),
+			}, nil
+		}
+		if isPrim {
+			return prim, nil
+		}
+		return backing, nil
+	}
+
+	if isPrim {
+		backing = prim
+	}
+	return factory(backing, props(m, "type", "logicalType"))
+}
+
+func (p *parser) parseField(m map[string]interface{}, namespace string, f *Field) error {
+	var err error
+
+	if f.Name, err = attrString(m, "name"); err != nil {
+		return err
+	}
+	if f.Doc, err = attrString(m, "doc"); err != nil {
+		return err
+	}
+	if f.Aliases, err = attrStrings(m, "aliases"); err != nil {
+		return err
+	}
+	if f.Order, err = attrString(m, "order"); err != nil {
+		return err
+	}
+
+	if err := validateOrder(f); err != nil {
+		return err
+	}
+
+	// A null default is kept distinct from an absent one.
+	f.ClearDefault()
+	if d, ok := m["default"]; ok {
+		f.SetDefault(d)
+	}
+
+	if f.Props, err = p.props(m, "field "+f.Name, "name", "type", "doc", "default", "aliases", "order"); err != nil {
+		return err
+	}
+
+	t, err := p.parse(m["type"], namespace)
+	if err != nil {
+		return err
+	}
+	f.Type = t
+
+	return nil
+}
+
+func (p *parser) parseRecord(m map[string]interface{}, namespace string, r *Record) error {
+	var err error
+
+	if err := p.parseName(m, namespace, &r.Name, &r.Namespace, &r.Aliases); err != nil {
+		return err
+	}
+	if r.Doc, err = attrString(m, "doc"); err != nil {
+		return err
+	}
+
+	if r.Props, err = p.props(m, "record "+r.Name, "type", "name", "namespace", "doc", "aliases", "fields"); err != nil {
+		return err
+	}
+
+	// Define the record before its fields so it can be referenced recursively.
+	if err := p.define(r.Name, r.Namespace, r); err != nil {
+		return err
+	}
+
+	namespace = namespaceOf(fullname(r.Name, r.Namespace))
+
+	fields, ok := m["fields"].([]interface{})
+	if !ok && m["fields"] != nil {
+		return fmt.Errorf("avroschema: record %v fields must be an array", r.Name)
+	}
+
+	r.Fields = make([]*Field, len(fields))
+	for i, v := range fields {
+		fm, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("avroschema: record %v field must be an object", r.Name)
+		}
+
+		f := &Field{}
+		if err := p.parseField(fm, namespace, f); err != nil {
+			return err
+		}
+		r.Fields[i] = f
+	}
+
+	return nil
+}
+
+func (p *parser) parseEnum(m map[string]interface{}, namespace string, e *Enum) error {
+	var err error
+
+	if err := p.parseName(m, namespace, &e.Name, &e.Namespace, &e.Aliases); err != nil {
+		return err
+	}
+	if e.Doc, err = attrString(m, "doc"); err != nil {
+		return err
+	}
+	if e.Symbols, err = attrStrings(m, "symbols"); err != nil {
+		return err
+	}
+	if e.Default, err = attrString(m, "default"); err != nil {
+		return err
+	}
+
+	if e.Props, err = p.props(m, "enum "+e.Name, "type", "name", "namespace", "doc", "aliases", "symbols", "default"); err != nil {
+		return err
+	}
+
+	if err := validateEnum(e); err != nil {
+		return err
+	}
+
+	return p.define(e.Name, e.Namespace, e)
+}
+
+func (p *parser) parseArray(m map[string]interface{}, namespace string, a *Array) error {
+	t, err := p.parse(m["items"], namespace)
+	if err != nil {
+		return err
+	}
+
+	a.Items = t
+	a.Props, err = p.props(m, "array", "type", "items")
+	return err
+}
+
+func (p *parser) parseMap(m map[string]interface{}, namespace string, x *Map) error {
+	t, err := p.parse(m["values"], namespace)
+	if err != nil {
+		return err
+	}
+
+	x.Values = t
+	x.Props, err = p.props(m, "map", "type", "values")
+	return err
+}
+
+func (p *parser) parseUnion(a []interface{}, namespace string, u *Union) error {
+	x := make(Union, len(a))
+	for i, e := range a {
+		t, err := p.parse(e, namespace)
+		if err != nil {
+			return err
+		}
+		x[i] = t
+	}
+
+	*u = x
+	return nil
+}
+
+func (p *parser) parseFixed(m map[string]interface{}, namespace string, f *Fixed) error {
+	var err error
+
+	if err := p.parseName(m, namespace, &f.Name, &f.Namespace, &f.Aliases); err != nil {
+		return err
+	}
+	if f.Size, err = attrInt(m, "size"); err != nil {
+		return err
+	}
+
+	if f.Props, err = p.props(m, "fixed "+f.Name, "type", "name", "namespace", "size", "aliases"); err != nil {
+		return err
+	}
+
+	if err := validateFixed(f); err != nil {
+		return err
+	}
+	max := p.opts.MaxFixedSize
+	if max == 0 {
+		max = DefaultMaxFixedSize
+	}
+	if max > 0 && f.Size > max {
+		return fmt.Errorf("avroschema: fixed %v size %d exceeds the limit of %d", f.Name, f.Size, max)
+	}
+
+	return p.define(f.Name, f.Namespace, f)
+}
+
+// parseName decodes the attributes common to named types. A type defined
+// without a namespace inherits the enclosing one unless its name is already
+// a full name.
+func (p *parser) parseName(m map[string]interface{}, enclosing string, name, namespace *string, aliases *[]string) error {
+	var err error
+
+	if *name, err = attrString(m, "name"); err != nil {
+		return err
+	}
+	if *namespace, err = attrString(m, "namespace"); err != nil {
+		return err
+	}
+	if *aliases, err = attrStrings(m, "aliases"); err != nil {
+		return err
+	}
+
+	if *namespace == "" && !strings.Contains(*name, ".") {
+		*namespace = enclosing
+	}
+
+	return nil
+}
+
+// define registers a named type so it can be referenced later in the document.
+func (p *parser) define(name, namespace string, s Schema) error {
+	fn := fullname(name, namespace)
+	if _, ok := p.names[fn]; ok {
+		return fmt.Errorf("avroschema: type %v is defined more than once", fn)
+	}
+
+	p.names[fn] = s
+	return nil
+}
+
+// lookup resolves a type name to a reference of a previously defined named type.
+func (p *parser) lookup(name, namespace string) (Schema, error) {
+	fn := fullname(name, namespace)
+	if s, ok := p.names[fn]; ok {
+		return &Reference{Name: fn, Schema: s}, nil
+	}
+
+	// Fall back to the null namespace.
+	if s, ok := p.names[name]; ok {
+		return &Reference{Name: name, Schema: s}, nil
+	}
+
+	// Fall back to the types defined outside the schema.
+	if s, ok := p.opts.Names[fn]; ok {
+		return &Reference{Name: fn, Schema: s}, nil
+	}
+	if s, ok := p.opts.Names[name]; ok {
+		return &Reference{Name: name, Schema: s}, nil
+	}
+
+	return nil, &unknownTypeError{name: name}
+}
+
+// unknownTypeError is returned for a reference to a type that has not been
+// defined.
+type unknownTypeError struct {
+	name string
+}
+
+func (e *unknownTypeError) Error() string {
+	return fmt.Sprintf("avroschema: unknown type %v", e.name)
+}
+
+// fullname returns the full name of a named type given the namespace in
+// effect where it is defined. Names containing a dot are already full names.
+func fullname(name, namespace string) string {
+	if namespace == "" || strings.Contains(name, ".") {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// namespaceOf returns the namespace portion of a full name.
+func namespaceOf(fullname string) string {
+	if i := strings.LastIndex(fullname, "."); i >= 0 {
+		return fullname[:i]
+	}
+	return ""
+}
+
+// decodeObject decodes an encoded schema object.
+func decodeObject(b []byte) (map[string]interface{}, error) {
+	var m map[string]interface{}
+	if err := decodeJSON(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// decodeJSON decodes a single JSON value, keeping numbers as json.Number so
+// that large integers such as long defaults are not rounded to a float64.
+func decodeJSON(b []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("avroschema: unexpected data after top-level value")
+	}
+	return nil
+}
+
+// props returns the custom attributes of a schema object, or an error naming
+// the first of them if unknown attributes are disallowed.
+func (p *parser) props(m map[string]interface{}, what string, known ...string) (map[string]interface{}, error) {
+	x := props(m, known...)
+
+	if _, ok := m["logicalType"]; ok || !p.opts.DisallowUnknownFields || len(x) == 0 {
+		return x, nil
+	}
+
+	keys := make([]string, 0, len(x))
+	for k := range x {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return nil, fmt.Errorf("avroschema: unknown attribute %q in %v", keys[0], what)
+}
+
+// props returns the attributes of a schema object that are not one of the
+// known keys, or nil if there are none.
+func props(m map[string]interface{}, known ...string) map[string]interface{} {
+	var x map[string]interface{}
+
+	for k, v := range m {
+		if contains(known, k) {
+			continue
+		}
+		if x == nil {
+			x = make(map[string]interface{})
+		}
+		x[k] = v
+	}
+
+	return x
+}
+
+func contains(a []string, s string) bool {
+	for _, x := range a {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// attrString returns a string attribute of a schema object, or the empty
+// string if it is absent.
+func attrString(m map[string]interface{}, key string) (string, error) {
+	v, ok := m[key]
+	if !ok || v == nil {
+		return "", nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("avroschema: attribute %v must be a string, got %T", key, v)
+	}
+	return s, nil
+}
+
+// attrStrings returns a string array attribute of a schema object, or nil if it
+// is absent.
+func attrStrings(m map[string]interface{}, key string) ([]string, error) {
+	v, ok := m[key]
+	if !ok || v == nil {
+		return nil, nil
+	}
+
+	a, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("avroschema: attribute %v must be an array of strings, got %T", key, v)
+	}
+
+	x := make([]string, len(a))
+	for i, e := range a {
+		s, ok := e.(string)
+		if !ok {
+			return nil, fmt.Errorf("avroschema: attribute %v must be an array of strings, got %T element", key, e)
+		}
+		x[i] = s
+	}
+	return x, nil
+}
+
+// attrInt returns an integer attribute of a schema object, or zero if it is
+// absent.
+func attrInt(m map[string]interface{}, key string) (int, error) {
+	v, ok := m[key]
+	if !ok || v == nil {
+		return 0, nil
+	}
+
+	if n, ok := v.(json.Number); ok {
+		v, _ = n.Float64()
+	}
+	if f, ok := v.(float64); ok && f == math.Trunc(f) && math.Abs(f) <= math.MaxInt32 {
+		return int(f), nil
+	}
+	if n, ok := toInt64(v); ok && n >= math.MinInt32 && n <= math.MaxInt32 {
+		return int(n), nil
+	}
+
+	return 0, fmt.Errorf("avroschema: attribute %v must be an integer, got %v", key, v)
+}
@@ -0,0 +1,266 @@
+package avro
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Project returns a reader schema for the record that keeps only the fields
+// named by the dotted paths, such as "user.address.zip". Records along a path
+// are pruned to the fields needed, keeping their names so they still resolve
+// against the original, while the last field of a path is kept whole. A path
+// may pass through a union with a single record branch, such as an optional
+// record. Fields are kept in the order they are defined, and a record pruned
+// the same way in two places is defined once and referred to thereafter.
+//
+// An error is returned if a path names a field that does not exist, or if a
+// named type would be pruned differently where it is used in two places,
+// including where it is kept whole in one place and pruned in another.
+func Project(r *Record, paths ...string) (*Record, error) {
+	split := make([][]string, len(paths))
+	for i, p := range paths {
+		split[i] = strings.Split(p, ".")
+	}
+
+	p := projector{
+		projections: make(map[string]string),
+		defined:     make(map[string]*Record),
+		written:     make(map[string]bool),
+	}
+	x, err := p.project(r, split, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the projection is self-contained by parsing it.
+	b, err := Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := Unmarshal(b); err != nil {
+		return nil, fmt.Errorf("avroschema: invalid projection: %s", strings.TrimPrefix(err.Error(), "avroschema: "))
+	}
+
+	return x.(*Record), nil
+}
+
+// projector prunes records, tracking how each named type is projected.
+type projector struct {
+	// projections holds the projection of each named type reached so far,
+	// keyed by full name: the sorted paths a record is pruned to, or
+	// wholeType if the type is kept whole.
+	projections map[string]string
+
+	// defined holds the pruned copies of records by full name.
+	defined map[string]*Record
+
+	// written holds the full names of the named types defined so far in the
+	// projection, in the order it is marshaled.
+	written map[string]bool
+}
+
+// wholeType is the projection of a named type that is kept whole.
+const wholeType = "*"
+
+// use records the projection of a named type used at the path, returning an
+// error if it has already been projected differently.
+func (p *projector) use(fullname, projection, path string) error {
+	if x, ok := p.projections[fullname]; ok && x != projection {
+		return fmt.Errorf("avroschema: %v would prune type %v differently than where it is first used", path, fullname)
+	}
+	p.projections[fullname] = projection
+	return nil
+}
+
+// project prunes the record to the paths, which are relative to it. The prefix
+// is the path of the record for error messages and the enclosing namespace is
+// the one in effect where it is defined. A record already pruned the same way
+// is returned as a reference to its copy.
+func (p *projector) project(r *Record, paths [][]string, prefix, enclosing string) (Schema, error) {
+	whole := make(map[string]bool)
+	nested := make(map[string][][]string)
+
+	keys := make([]string, len(paths))
+	for i, path := range paths {
+		if _, ok := r.Field(path[0]); !ok {
+			return nil, fmt.Errorf("avroschema: %v%v is not a field of record %v", prefix, path[0], r.Name)
+		}
+		if len(path) == 1 {
+			whole[path[0]] = true
+		} else {
+			nested[path[0]] = append(nested[path[0]], path[1:])
+		}
+		keys[i] = strings.Join(path, ".")
+	}
+	sort.Strings(keys)
+
+	name, namespace := splitName(r.Name, r.Namespace, enclosing)
+	fn := fullname(name, namespace)
+	if err := p.use(fn, strings.Join(keys, ","), strings.TrimSuffix(prefix, ".")); err != nil {
+		return nil, err
+	}
+	if x, ok := p.defined[fn]; ok {
+		return &Reference{Name: fn, Schema: x}, nil
+	}
+
+	x := &Record{
+		Name:      r.Name,
+		Namespace: r.Namespace,
+		Doc:       r.Doc,
+		Aliases:   r.Aliases,
+		Props:     r.Props,
+	}
+	p.defined[fn] = x
+	p.written[fn] = true
+
+	for _, f := range r.Fields {
+		if !whole[f.Name] && nested[f.Name] == nil {
+			continue
+		}
+
+		pf := *f
+		if whole[f.Name] {
+			// The named types within a field kept whole are kept whole.
+			var types []namedType
+			collectTypes(f.Type, namespace, make(map[Schema]bool), &types)
+			for _, t := range types {
+				if err := p.use(t.fullname, wholeType, prefix+f.Name); err != nil {
+					return nil, err
+				}
+			}
+			pf.Type, _ = p.inline(f.Type, namespace)
+		} else {
+			t, err := p.projectType(f.Type, nested[f.Name], prefix+f.Name, namespace)
+			if err != nil {
+				return nil, err
+			}
+			pf.Type = t
+		}
+
+		x.Fields = append(x.Fields, &pf)
+	}
+
+	return x, nil
+}
+
+// projectType prunes the record a path passes through.
+func (p *projector) projectType(s Schema, paths [][]string, path, enclosing string) (Schema, error) {
+	switch x := s.(type) {
+	case *Record:
+		return p.project(x, paths, path+".", enclosing)
+
+	case *Reference:
+		if r, ok := x.Schema.(*Record); ok {
+			return p.project(r, paths, path+".", namespaceOf(fullname(x.Name, enclosing)))
+		}
+
+	case Union:
+		i := -1
+		for j, t := range x {
+			if ref, ok := t.(*Reference); ok {
+				t = ref.Schema
+			}
+			if _, ok := t.(*Record); ok {
+				if i >= 0 {
+					return nil, fmt.Errorf("avroschema: %v has more than one record branch", path)
+				}
+				i = j
+			}
+		}
+		if i < 0 {
+			break
+		}
+
+		t, err := p.projectType(x[i], paths, path, enclosing)
+		if err != nil {
+			return nil, err
+		}
+
+		u := make(Union, len(x))
+		copy(u, x)
+		u[i] = t
+		return u, nil
+	}
+
+	return nil, fmt.Errorf("avroschema: %v is not a record", path)
+}
+
+// inline returns the type of a field kept whole with the definitions of the
+// named types it refers to but that are not yet defined in the projection, such
+// as those defined in a field that is dropped, in place of the first reference
+// to each. The type is copied where it changes, which is reported.
+func (p *projector) inline(s Schema, enclosing string) (Schema, bool) {
+	switch x := s.(type) {
+	case *Reference:
+		if x.Schema != nil && !p.written[x.Name] {
+			t, _ := p.inline(x.Schema, namespaceOf(x.Name))
+			return t, true
+		}
+
+	case *Record:
+		name, namespace := splitName(x.Name, x.Namespace, enclosing)
+		p.written[fullname(name, namespace)] = true
+
+		var fields []*Field
+		for i, f := range x.Fields {
+			t, ok := p.inline(f.Type, namespace)
+			if !ok {
+				continue
+			}
+			if fields == nil {
+				fields = make([]*Field, len(x.Fields))
+				copy(fields, x.Fields)
+			}
+			pf := *f
+			pf.Type = t
+			fields[i] = &pf
+		}
+		if fields != nil {
+			r := *x
+			r.Fields = fields
+			return &r, true
+		}
+
+	case *Array:
+		if t, ok := p.inline(x.Items, enclosing); ok {
+			a := *x
+			a.Items = t
+			return &a, true
+		}
+
+	case *Map:
+		if t, ok := p.inline(x.Values, enclosing); ok {
+			m := *x
+			m.Values = t
+			return &m, true
+		}
+
+	case Union:
+		var u Union
+		for i, t := range x {
+			b, ok := p.inline(t, enclosing)
+			if !ok {
+				continue
+			}
+			if u == nil {
+				u = make(Union, len(x))
+				copy(u, x)
+			}
+			u[i] = b
+		}
+		if u != nil {
+			return u, true
+		}
+
+	default:
+		// Enums, fixed types and the logical types annotating them.
+		var types []namedType
+		collectTypes(s, enclosing, make(map[Schema]bool), &types)
+		for _, t := range types {
+			p.written[t.fullname] = true
+		}
+	}
+
+	return s, false
+}
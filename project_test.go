@@ -0,0 +1,201 @@
+package avro
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestProject(t *testing.T) {
+	s, err := Unmarshal([]byte(`{
+		"type": "record",
+		"name": "Payment",
+		"namespace": "com.example",
+		"fields": [
+			{"name": "id", "type": "string"},
+			{"name": "amount", "type": "double"},
+			{"name": "user", "type": {
+				"type": "record",
+				"name": "User",
+				"fields": [
+					{"name": "name", "type": "string"},
+					{"name": "address", "type": ["null", {
+						"type": "record",
+						"name": "Address",
+						"fields": [
+							{"name": "street", "type": "string"},
+							{"name": "zip", "type": "string"}
+						]
+					}]}
+				]
+			}},
+			{"name": "referrer", "type": ["null", "User"], "default": null},
+			{"name": "tags", "type": {"type": "array", "items": "string"}}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := s.(*Record)
+
+	tests := []struct {
+		Paths []string
+		Exp   string
+		Error string
+	}{
+		{
+			Paths: []string{"user.address.zip", "amount"},
+			Exp: `{"type":"record","name":"Payment","namespace":"com.example","fields":[` +
+				`{"name":"amount","type":"double"},` +
+				`{"name":"user","type":{"type":"record","name":"User","namespace":"com.example","fields":[` +
+				`{"name":"address","type":["null",{"type":"record","name":"Address","namespace":"com.example","fields":[` +
+				`{"name":"zip","type":"string"}]}]}]}}]}`,
+		},
+		{
+			Paths: []string{"tags", "id", "tags"},
+			Exp: `{"type":"record","name":"Payment","namespace":"com.example","fields":[` +
+				`{"name":"id","type":"string"},{"name":"tags","type":{"type":"array","items":"string"}}]}`,
+		},
+		{
+			// Through a reference whose definition is not kept.
+			Paths: []string{"referrer.name"},
+			Exp: `{"type":"record","name":"Payment","namespace":"com.example","fields":[` +
+				`{"name":"referrer","type":["null",{"type":"record","name":"User","namespace":"com.example","fields":[` +
+				`{"name":"name","type":"string"}]}],"default":null}]}`,
+		},
+		{
+			// Kept whole through a reference whose definition is not kept.
+			Paths: []string{"referrer"},
+			Exp: `{"type":"record","name":"Payment","namespace":"com.example","fields":[` +
+				`{"name":"referrer","type":["null",{"type":"record","name":"User","namespace":"com.example","fields":[` +
+				`{"name":"name","type":"string"},` +
+				`{"name":"address","type":["null",{"type":"record","name":"Address","namespace":"com.example","fields":[` +
+				`{"name":"street","type":"string"},{"name":"zip","type":"string"}]}]}]}],"default":null}]}`,
+		},
+		{
+			Paths: []string{"user", "referrer"},
+			Exp: `{"type":"record","name":"Payment","namespace":"com.example","fields":[` +
+				`{"name":"user","type":{"type":"record","name":"User","namespace":"com.example","fields":[` +
+				`{"name":"name","type":"string"},` +
+				`{"name":"address","type":["null",{"type":"record","name":"Address","namespace":"com.example","fields":[` +
+				`{"name":"street","type":"string"},{"name":"zip","type":"string"}]}]}]}},` +
+				`{"name":"referrer","type":["null","com.example.User"],"default":null}]}`,
+		},
+		{
+			Paths: []string{"user.address.city"},
+			Error: "avroschema: user.address.city is not a field of record Address",
+		},
+		{
+			Paths: []string{"tags.length"},
+			Error: "avroschema: tags is not a record",
+		},
+		{
+			// A record pruned the same way twice is defined once.
+			Paths: []string{"user.name", "referrer.name"},
+			Exp: `{"type":"record","name":"Payment","namespace":"com.example","fields":[` +
+				`{"name":"user","type":{"type":"record","name":"User","namespace":"com.example","fields":[` +
+				`{"name":"name","type":"string"}]}},` +
+				`{"name":"referrer","type":["null","com.example.User"],"default":null}]}`,
+		},
+		{
+			// A named type cannot be pruned two ways.
+			Paths: []string{"user.name", "referrer.address"},
+			Error: "avroschema: referrer would prune type com.example.User differently than where it is first used",
+		},
+		{
+			// Nor kept whole where it is pruned elsewhere.
+			Paths: []string{"user.name", "referrer"},
+			Error: "avroschema: referrer would prune type com.example.User differently than where it is first used",
+		},
+		{
+			Paths: []string{"user", "referrer.address.zip"},
+			Error: "avroschema: referrer would prune type com.example.User differently than where it is first used",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			r, err := Project(writer, test.Paths...)
+			checkError(t, err, test.Error)
+			if err != nil {
+				return
+			}
+
+			b, err := Marshal(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != test.Exp {
+				t.Errorf("expected %s, got %s", test.Exp, b)
+			}
+
+			// The projection means the same once parsed.
+			x, err := Unmarshal(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !Equal(r, x) {
+				t.Errorf("expected %v, got %v", r, x)
+			}
+		})
+	}
+}
+
+func TestProjectInline(t *testing.T) {
+	s, err := Unmarshal([]byte(`{
+		"type": "record",
+		"name": "R",
+		"fields": [
+			{"name": "a", "type": {"type": "record", "name": "Addr", "fields": [{"name": "zip", "type": "string"}]}},
+			{"name": "b", "type": "Addr"},
+			{"name": "c", "type": {"type": "record", "name": "Wrapper", "fields": [
+				{"name": "addr", "type": "Addr"},
+				{"name": "others", "type": {"type": "array", "items": "Addr"}}
+			]}}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Paths []string
+		Exp   string
+	}{
+		{
+			Paths: []string{"b"},
+			Exp: `{"type":"record","name":"R","fields":[` +
+				`{"name":"b","type":{"type":"record","name":"Addr","fields":[{"name":"zip","type":"string"}]}}]}`,
+		},
+		{
+			// The first reference within a record kept whole is defined.
+			Paths: []string{"c"},
+			Exp: `{"type":"record","name":"R","fields":[` +
+				`{"name":"c","type":{"type":"record","name":"Wrapper","fields":[` +
+				`{"name":"addr","type":{"type":"record","name":"Addr","fields":[{"name":"zip","type":"string"}]}},` +
+				`{"name":"others","type":{"type":"array","items":"Addr"}}]}}]}`,
+		},
+		{
+			Paths: []string{"b", "c"},
+			Exp: `{"type":"record","name":"R","fields":[` +
+				`{"name":"b","type":{"type":"record","name":"Addr","fields":[{"name":"zip","type":"string"}]}},` +
+				`{"name":"c","type":{"type":"record","name":"Wrapper","fields":[` +
+				`{"name":"addr","type":"Addr"},{"name":"others","type":{"type":"array","items":"Addr"}}]}}]}`,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			r, err := Project(s.(*Record), test.Paths...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := Marshal(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != test.Exp {
+				t.Errorf("expected %s, got %s", test.Exp, b)
+			}
+		})
+	}
+}
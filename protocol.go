@@ -0,0 +1,622 @@
+package avro
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Protocol is an Avro protocol, a named collection of types and the messages
+// of an RPC interface that uses them.
+// https://avro.apache.org/docs/current/spec.html#Protocol+Declaration
+type Protocol struct {
+	Protocol  string
+	Namespace string
+	Doc       string
+
+	// Types are the named types declared by the protocol, in order.
+	Types []Schema
+
+	// Messages are the messages of the protocol, in the order they are
+	// declared, which the MD5 depends on.
+	Messages []*Message
+}
+
+// Message returns the protocol's message with the name.
+func (p *Protocol) Message(name string) (*Message, bool) {
+	for _, m := range p.Messages {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+// Message is a protocol message. Request holds the parameters, which are
+// declared like record fields. Errors holds the error types the message may
+// return in addition to the implicit string error.
+type Message struct {
+	Name     string
+	Doc      string
+	Request  []*Field
+	Response Schema
+	Errors   []Schema
+	OneWay   bool
+}
+
+func (p *Protocol) MarshalJSON() ([]byte, error) {
+	o := object{
+		{"protocol", p.Protocol},
+	}
+
+	if p.Namespace != "" {
+		o = append(o, member{"namespace", p.Namespace})
+	}
+
+	if p.Doc != "" {
+		o = append(o, member{"doc", p.Doc})
+	}
+
+	types := p.Types
+	if types == nil {
+		types = []Schema{}
+	}
+	o = append(o, member{"types", types})
+
+	messages := make(object, len(p.Messages))
+	for i, m := range p.Messages {
+		messages[i] = member{m.Name, m}
+	}
+	o = append(o, member{"messages", messages})
+
+	return json.Marshal(o)
+}
+
+// MD5 returns the MD5 hash of the protocol's JSON form, which the RPC
+// handshake uses to identify protocols. The JSON is normalized as the Java
+// implementation writes it, so that both hash a protocol the same way: it is
+// compact, messages are in the order they are declared, named types are
+// written in full where they are first used and by name thereafter, and names
+// and namespaces are written relative to the namespace in effect. Custom
+// attributes follow those of the spec in key order.
+// https://avro.apache.org/docs/current/spec.html#handshake
+func (p *Protocol) MD5() ([16]byte, error) {
+	b, err := p.normalize()
+	if err != nil {
+		return [16]byte{}, err
+	}
+	return md5.Sum(b), nil
+}
+
+// UnmarshalJSON decodes a protocol. Types may refer to the types declared
+// before them, and messages to any declared type.
+func (p *Protocol) UnmarshalJSON(b []byte) error {
+	m, err := decodeObject(b)
+	if err != nil {
+		return err
+	}
+
+	var x Protocol
+
+	if x.Protocol, err = attrString(m, "protocol"); err != nil {
+		return err
+	}
+	if x.Namespace, err = attrString(m, "namespace"); err != nil {
+		return err
+	}
+	if x.Doc, err = attrString(m, "doc"); err != nil {
+		return err
+	}
+
+	types, ok := m["types"].([]interface{})
+	if !ok && m["types"] != nil {
+		return fmt.Errorf("avroschema: protocol %v types must be an array", x.Protocol)
+	}
+
+	sp := newParser(&Parser{})
+	for _, t := range types {
+		s, err := sp.parse(t, x.Namespace)
+		if err != nil {
+			return err
+		}
+		x.Types = append(x.Types, s)
+	}
+
+	messages, ok := m["messages"].(map[string]interface{})
+	if !ok && m["messages"] != nil {
+		return fmt.Errorf("avroschema: protocol %v messages must be an object", x.Protocol)
+	}
+
+	// The messages are decoded into a map, so their order is read separately.
+	var raw struct {
+		Messages json.RawMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	names, err := objectKeys(raw.Messages)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		mm, ok := messages[name].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("avroschema: message %v must be an object", name)
+		}
+
+		msg, err := sp.parseMessage(mm, x.Namespace)
+		if err != nil {
+			return fmt.Errorf("avroschema: message %v: %s", name, strings.TrimPrefix(err.Error(), "avroschema: "))
+		}
+
+		msg.Name = name
+		x.Messages = append(x.Messages, msg)
+	}
+
+	*p = x
+	return nil
+}
+
+// objectKeys returns the keys of an encoded JSON object in the order they are
+// written, once each. It returns no keys for an empty or null value.
+func objectKeys(b []byte) ([]string, error) {
+	if len(b) == 0 || string(b) == "null" {
+		return nil, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	var (
+		keys []string
+		seen = make(map[string]bool)
+	)
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+
+		if k := t.(string); !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (m *Message) MarshalJSON() ([]byte, error) {
+	var o object
+
+	if m.Doc != "" {
+		o = append(o, member{"doc", m.Doc})
+	}
+
+	request := m.Request
+	if request == nil {
+		request = []*Field{}
+	}
+	o = append(o, member{"request", request})
+
+	response := m.Response
+	if response == nil {
+		response = Null
+	}
+	o = append(o, member{"response", response})
+
+	if len(m.Errors) > 0 {
+		o = append(o, member{"errors", m.Errors})
+	}
+
+	if m.OneWay {
+		o = append(o, member{"one-way", true})
+	}
+
+	return json.Marshal(o)
+}
+
+func (p *parser) parseMessage(m map[string]interface{}, namespace string) (*Message, error) {
+	var (
+		msg = &Message{}
+		err error
+	)
+
+	if msg.Doc, err = attrString(m, "doc"); err != nil {
+		return nil, err
+	}
+
+	request, ok := m["request"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("avroschema: request must be an array")
+	}
+	for _, v := range request {
+		fm, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("avroschema: request parameter must be an object")
+		}
+
+		f := &Field{}
+		if err := p.parseField(fm, namespace, f); err != nil {
+			return nil, err
+		}
+		msg.Request = append(msg.Request, f)
+	}
+
+	if msg.Response, err = p.parse(m["response"], namespace); err != nil {
+		return nil, err
+	}
+
+	if v, ok := m["errors"]; ok {
+		errs, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("avroschema: errors must be an array")
+		}
+		for _, e := range errs {
+			s, err := p.parse(e, namespace)
+			if err != nil {
+				return nil, err
+			}
+			msg.Errors = append(msg.Errors, s)
+		}
+	}
+
+	if v, ok := m["one-way"]; ok {
+		if msg.OneWay, ok = v.(bool); !ok {
+			return nil, fmt.Errorf("avroschema: one-way must be a boolean")
+		}
+	}
+
+	if msg.OneWay && (msg.Response != Null || len(msg.Errors) > 0) {
+		return nil, fmt.Errorf("avroschema: one-way messages must have a null response and no errors")
+	}
+
+	return msg, nil
+}
+
+// normalize returns the protocol's JSON as the Java implementation writes it
+// with Protocol.toString, from which MD5 is taken.
+func (p *Protocol) normalize() ([]byte, error) {
+	w := protocolWriter{space: p.Namespace, written: make(map[string]bool)}
+
+	o := object{
+		{"protocol", p.Protocol},
+	}
+
+	if p.Namespace != "" {
+		o = append(o, member{"namespace", p.Namespace})
+	}
+
+	if p.Doc != "" {
+		o = append(o, member{"doc", p.Doc})
+	}
+
+	// A type written in full within one before it is not written again.
+	types := []interface{}{}
+	for _, t := range p.Types {
+		if n, ok := t.(Named); ok && w.written[fullname(n.Fullname(), p.Namespace)] {
+			continue
+		}
+		v, err := w.schema(t)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, v)
+	}
+	o = append(o, member{"types", types})
+
+	messages := make(object, len(p.Messages))
+	for i, m := range p.Messages {
+		v, err := w.message(m)
+		if err != nil {
+			return nil, err
+		}
+		messages[i] = member{m.Name, v}
+	}
+	o = append(o, member{"messages", messages})
+
+	b, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	return unescapeHTML(b), nil
+}
+
+// protocolWriter builds the JSON of a protocol's types and messages in the
+// form the Java implementation writes it.
+type protocolWriter struct {
+	// space is the namespace in effect.
+	space string
+
+	// written holds the full names of the named types written so far.
+	written map[string]bool
+}
+
+func (w *protocolWriter) message(m *Message) (object, error) {
+	var o object
+
+	if m.Doc != "" {
+		o = append(o, member{"doc", m.Doc})
+	}
+
+	request, err := w.fields(m.Request)
+	if err != nil {
+		return nil, err
+	}
+	o = append(o, member{"request", request})
+
+	if m.OneWay {
+		return append(o, member{"response", Null}, member{"one-way", true}), nil
+	}
+
+	response := m.Response
+	if response == nil {
+		response = Null
+	}
+	v, err := w.schema(response)
+	if err != nil {
+		return nil, err
+	}
+	o = append(o, member{"response", v})
+
+	if len(m.Errors) > 0 {
+		v, err := w.schema(Union(m.Errors))
+		if err != nil {
+			return nil, err
+		}
+		o = append(o, member{"errors", v})
+	}
+
+	return o, nil
+}
+
+func (w *protocolWriter) fields(fields []*Field) ([]interface{}, error) {
+	a := []interface{}{}
+	for _, f := range fields {
+		t, err := w.schema(f.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		o := object{
+			{"name", f.Name},
+			{"type", t},
+		}
+
+		if f.Doc != "" {
+			o = append(o, member{"doc", f.Doc})
+		}
+
+		if f.HasDefault() {
+			o = append(o, member{"default", f.Default})
+		}
+
+		if f.Order != "" && f.Order != "ascending" {
+			o = append(o, member{"order", f.Order})
+		}
+
+		if len(f.Aliases) > 0 {
+			o = append(o, member{"aliases", f.Aliases})
+		}
+
+		a = append(a, o.withProps(f.Props))
+	}
+	return a, nil
+}
+
+func (w *protocolWriter) schema(s Schema) (interface{}, error) {
+	switch x := s.(type) {
+	case Primitive:
+		return x, nil
+
+	case *Record:
+		typ := "record"
+		if x.Error {
+			typ = "error"
+		}
+		o, ref, space := w.named(typ, x.Name, x.Namespace)
+		if o == nil {
+			return ref, nil
+		}
+
+		if x.Doc != "" {
+			o = append(o, member{"doc", x.Doc})
+		}
+
+		saved := w.space
+		w.space = space
+		fields, err := w.fields(x.Fields)
+		w.space = saved
+		if err != nil {
+			return nil, err
+		}
+		o = append(o, member{"fields", fields})
+
+		return w.aliases(o.withProps(x.Props), x.Aliases, space), nil
+
+	case *Enum:
+		o, ref, space := w.named("enum", x.Name, x.Namespace)
+		if o == nil {
+			return ref, nil
+		}
+
+		if x.Doc != "" {
+			o = append(o, member{"doc", x.Doc})
+		}
+
+		o = append(o, member{"symbols", x.Symbols})
+
+		if x.Default != "" {
+			o = append(o, member{"default", x.Default})
+		}
+
+		return w.aliases(o.withProps(x.Props), x.Aliases, space), nil
+
+	case *Fixed:
+		return w.fixed(x, nil), nil
+
+	case *FixedDecimal:
+		return w.fixed(x.Fixed, object{
+			{"logicalType", "decimal"},
+			{"precision", x.Precision},
+			{"scale", x.Scale},
+		}), nil
+
+	case *duration:
+		return w.fixed(x.fixed(), object{{"logicalType", "duration"}}), nil
+
+	case *Array:
+		items, err := w.schema(x.Items)
+		if err != nil {
+			return nil, err
+		}
+		return object{{"type", "array"}, {"items", items}}.withProps(x.Props), nil
+
+	case *Map:
+		values, err := w.schema(x.Values)
+		if err != nil {
+			return nil, err
+		}
+		return object{{"type", "map"}, {"values", values}}.withProps(x.Props), nil
+
+	case Union:
+		a := make([]interface{}, len(x))
+		for i, t := range x {
+			v, err := w.schema(t)
+			if err != nil {
+				return nil, err
+			}
+			a[i] = v
+		}
+		return a, nil
+
+	case *Reference:
+		if x.Schema != nil && !w.written[x.Name] {
+			return w.schema(x.Schema)
+		}
+		name, namespace := splitName(x.Name, "", "")
+		return w.qualify(name, namespace), nil
+	}
+
+	// The remaining types annotate primitives, which have no names.
+	b, err := marshalSchema(s)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(b), nil
+}
+
+// fixed builds a fixed type, followed by the attributes of the logical type
+// annotating it, if any.
+func (w *protocolWriter) fixed(f *Fixed, logical object) interface{} {
+	o, ref, space := w.named("fixed", f.Name, f.Namespace)
+	if o == nil {
+		return ref
+	}
+
+	o = append(o, member{"size", f.Size})
+	o = append(o, logical...)
+
+	return w.aliases(o.withProps(f.Props), f.Aliases, space)
+}
+
+// named returns the type and name attributes of a named type being written in
+// full, with the namespace defined by it. If the type has already been
+// written, the object is nil and ref is its name instead. The namespace is
+// written only if it differs from the one in effect.
+func (w *protocolWriter) named(typ, name, namespace string) (o object, ref, space string) {
+	name, namespace = splitName(name, namespace, w.space)
+	fn := fullname(name, namespace)
+	if w.written[fn] {
+		return nil, w.qualify(name, namespace), namespace
+	}
+	w.written[fn] = true
+
+	o = object{
+		{"type", typ},
+		{"name", name},
+	}
+	if namespace != w.space {
+		o = append(o, member{"namespace", namespace})
+	}
+	return o, "", namespace
+}
+
+// aliases appends the aliases of a named type, qualified relative to its
+// namespace.
+func (w *protocolWriter) aliases(o object, aliases []string, namespace string) object {
+	if len(aliases) == 0 {
+		return o
+	}
+
+	a := make([]string, len(aliases))
+	for i, alias := range fullAliases(aliases, namespace) {
+		name, space := splitName(alias, "", "")
+		if space == namespace {
+			a[i] = name
+		} else {
+			a[i] = alias
+		}
+	}
+	return append(o, member{"aliases", a})
+}
+
+// qualify returns the name relative to the namespace in effect.
+func (w *protocolWriter) qualify(name, namespace string) string {
+	if namespace == w.space {
+		return name
+	}
+	return fullname(name, namespace)
+}
+
+// unescapeHTML undoes the escaping of <, >, & and the line and paragraph
+// separators in strings by encoding/json, which other implementations do not
+// escape.
+func unescapeHTML(b []byte) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < len(b); i++ {
+		if b[i] != '\\' || i+1 == len(b) {
+			buf.WriteByte(b[i])
+			continue
+		}
+
+		if b[i+1] == 'u' && i+6 <= len(b) {
+			switch string(b[i+2 : i+6]) {
+			case "003c":
+				buf.WriteByte('<')
+				i += 5
+				continue
+			case "003e":
+				buf.WriteByte('>')
+				i += 5
+				continue
+			case "0026":
+				buf.WriteByte('&')
+				i += 5
+				continue
+			case "2028":
+				buf.WriteString("\u2028")
+				i += 5
+				continue
+			case "2029":
+				buf.WriteString("\u2029")
+				i += 5
+				continue
+			}
+		}
+
+		// Any other escape is kept, including an escaped backslash, so
+		// that what follows it is not mistaken for an escape.
+		buf.Write(b[i : i+2])
+		i++
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,199 @@
+package avro
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestProtocol(t *testing.T) {
+	// The example protocol from the spec.
+	b := []byte(`{
+		"namespace": "com.acme",
+		"protocol": "HelloWorld",
+		"doc": "Protocol Greetings",
+		"types": [
+			{"name": "Greeting", "type": "record", "fields": [{"name": "message", "type": "string"}]},
+			{"name": "Curse", "type": "error", "fields": [{"name": "message", "type": "string"}]}
+		],
+		"messages": {
+			"hello": {
+				"doc": "Say hello.",
+				"request": [{"name": "greeting", "type": "Greeting"}],
+				"response": "Greeting",
+				"errors": ["Curse"]
+			},
+			"ping": {
+				"request": [],
+				"response": "null",
+				"one-way": true
+			}
+		}
+	}`)
+
+	var p Protocol
+	if err := json.Unmarshal(b, &p); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.Types) != 2 || len(p.Messages) != 2 {
+		t.Fatalf("expected 2 types and 2 messages, got %d and %d", len(p.Types), len(p.Messages))
+	}
+	if r := p.Types[1].(*Record); !r.Error || r.Namespace != "com.acme" {
+		t.Errorf("expected error type in com.acme, got %v", r)
+	}
+
+	hello, ok := p.Message("hello")
+	if !ok || p.Messages[0] != hello {
+		t.Fatalf("expected hello to be the first message")
+	}
+	if ref, ok := hello.Response.(*Reference); !ok || ref.Schema != p.Types[0] {
+		t.Errorf("expected response to refer to Greeting, got %v", hello.Response)
+	}
+	if ref, ok := hello.Errors[0].(*Reference); !ok || ref.Name != "com.acme.Curse" {
+		t.Errorf("expected error Curse, got %v", hello.Errors)
+	}
+	if ping, _ := p.Message("ping"); !ping.OneWay {
+		t.Errorf("expected ping to be one-way")
+	}
+
+	out, err := json.Marshal(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := `{"protocol":"HelloWorld","namespace":"com.acme","doc":"Protocol Greetings","types":[` +
+		`{"type":"record","name":"Greeting","namespace":"com.acme","fields":[{"name":"message","type":"string"}]},` +
+		`{"type":"error","name":"Curse","namespace":"com.acme","fields":[{"name":"message","type":"string"}]}],` +
+		`"messages":{` +
+		`"hello":{"doc":"Say hello.","request":[{"name":"greeting","type":"com.acme.Greeting"}],"response":"com.acme.Greeting","errors":["com.acme.Curse"]},` +
+		`"ping":{"request":[],"response":"null","one-way":true}}}`
+	if string(out) != exp {
+		t.Errorf("expected %s, got %s", exp, out)
+	}
+
+	// Messages may only use declared types.
+	err = json.Unmarshal([]byte(`{"protocol": "P", "messages": {"m": {"request": [], "response": "Missing"}}}`), &p)
+	checkError(t, err, "avroschema: message m: unknown type Missing")
+}
+
+func TestProtocolMD5(t *testing.T) {
+	// The example protocol from the spec, which is hashed from its JSON as
+	// written by the Java implementation's Protocol.toString.
+	spec := `{
+		"namespace": "com.acme",
+		"protocol": "HelloWorld",
+		"doc": "Protocol Greetings",
+		"types": [
+			{"name": "Greeting", "type": "record", "fields": [{"name": "message", "type": "string"}]},
+			{"name": "Curse", "type": "error", "fields": [{"name": "message", "type": "string"}]}
+		],
+		"messages": {
+			"hello": {
+				"doc": "Say hello.",
+				"request": [{"name": "greeting", "type": "Greeting"}],
+				"response": "Greeting",
+				"errors": ["Curse"]
+			}
+		}
+	}`
+
+	var p Protocol
+	if err := json.Unmarshal([]byte(spec), &p); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := p.normalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{"protocol":"HelloWorld","namespace":"com.acme","doc":"Protocol Greetings","types":[` +
+		`{"type":"record","name":"Greeting","fields":[{"name":"message","type":"string"}]},` +
+		`{"type":"error","name":"Curse","fields":[{"name":"message","type":"string"}]}],` +
+		`"messages":{"hello":{"doc":"Say hello.","request":[{"name":"greeting","type":"Greeting"}],"response":"Greeting","errors":["Curse"]}}}`
+	if string(b) != exp {
+		t.Errorf("expected %s, got %s", exp, b)
+	}
+
+	sum, err := p.MD5()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := fmt.Sprintf("%x", sum); x != "36fc23c5954921b259a33c6be7c6b013" {
+		t.Errorf("expected MD5 36fc23c5954921b259a33c6be7c6b013, got %v", x)
+	}
+
+	tests := []struct {
+		Doc string
+		Exp string
+	}{
+		{
+			// Names outside the protocol's namespace are qualified, and
+			// types are written in full where they are first used.
+			Doc: `{"protocol": "Echo", "namespace": "com.acme", "types": [
+				{"type": "record", "name": "Ping", "fields": [
+					{"name": "id", "type": {"type": "fixed", "name": "org.other.Id", "size": 4, "aliases": ["Key", "com.acme.Id"]}},
+					{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B"]}, "order": "ascending"},
+					{"name": "note", "type": "string", "doc": "a <b> & c", "default": "", "order": "descending"}
+				]}
+			], "messages": {
+				"echo": {"request": [{"name": "p", "type": "Ping"}], "response": "org.other.Id"},
+				"drop": {"request": [], "response": "null", "one-way": true}
+			}}`,
+			Exp: `{"protocol":"Echo","namespace":"com.acme","types":[` +
+				`{"type":"record","name":"Ping","fields":[` +
+				`{"name":"id","type":{"type":"fixed","name":"Id","namespace":"org.other","size":4,"aliases":["Key","com.acme.Id"]}},` +
+				`{"name":"kind","type":{"type":"enum","name":"Kind","symbols":["A","B"]}},` +
+				`{"name":"note","type":"string","doc":"a <b> & c","default":"","order":"descending"}]}],` +
+				`"messages":{"echo":{"request":[{"name":"p","type":"Ping"}],"response":"org.other.Id"},` +
+				`"drop":{"request":[],"response":"null","one-way":true}}}`,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			var p Protocol
+			if err := json.Unmarshal([]byte(test.Doc), &p); err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := p.normalize()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != test.Exp {
+				t.Errorf("expected %s, got %s", test.Exp, b)
+			}
+
+			sum, err := p.MD5()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sum != md5.Sum(b) {
+				t.Errorf("expected MD5 of the normalized protocol")
+			}
+
+			// A type declared after the type it is defined within is
+			// not written again.
+			var types []namedType
+			collectTypes(p.Types[0], p.Namespace, make(map[Schema]bool), &types)
+			for _, x := range types[1:] {
+				p.Types = append(p.Types, x.schema)
+			}
+			if b, _ := p.normalize(); string(b) != test.Exp {
+				t.Errorf("expected %s, got %s", test.Exp, b)
+			}
+		})
+	}
+
+	// Messages are hashed in the order they are declared.
+	a, _ := p.MD5()
+	p.Messages = append(p.Messages, &Message{Name: "ping", Response: Null, OneWay: true})
+	b1, _ := p.MD5()
+	p.Messages[0], p.Messages[1] = p.Messages[1], p.Messages[0]
+	b2, _ := p.MD5()
+	if a == b1 || b1 == b2 {
+		t.Errorf("expected different hashes, got %x, %x and %x", a, b1, b2)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"sort"
+	"sync/atomic"
 )
 
 const (
@@ -31,8 +34,66 @@ var (
 )
 
 // Marshal marshals a schema to its binary representation which is encoded JSON.
+// The output is compact and attributes are written in a fixed order, so equal
+// schemas always marshal to the same bytes.
 func Marshal(s Schema) ([]byte, error) {
-	return json.Marshal(s)
+	return marshalSchema(s)
+}
+
+// marshalSchema marshals a schema nested in another. A custom logical type
+// that does not implement json.Marshaler is written as the type it annotates
+// with its name as the logicalType, rather than as its Go struct.
+func marshalSchema(s Schema) ([]byte, error) {
+	if _, ok := s.(json.Marshaler); ok || s == nil {
+		return json.Marshal(s)
+	}
+	u := Underlying(s)
+	if u == s {
+		return json.Marshal(s)
+	}
+
+	b, err := marshalSchema(u)
+	if err != nil {
+		return nil, err
+	}
+	if b[0] != '{' {
+		return json.Marshal(object{{"type", u}, {"logicalType", s.Type()}})
+	}
+
+	name, err := json.Marshal(s.Type())
+	if err != nil {
+		return nil, err
+	}
+	b = append(b[:len(b)-1], `,"logicalType":`...)
+	b = append(b, name...)
+	return append(b, '}'), nil
+}
+
+// marshalSchemas marshals a list of schemas as marshalSchema does.
+func marshalSchemas(a []Schema) ([]byte, error) {
+	x := make([]json.RawMessage, len(a))
+	for i, s := range a {
+		b, err := marshalSchema(s)
+		if err != nil {
+			return nil, err
+		}
+		x[i] = b
+	}
+	return json.Marshal(x)
+}
+
+// WriteTo writes the schema as Marshal encodes it to the writer, returning the
+// number of bytes written. It is a convenience for writing a schema into a
+// larger format, such as a file header or a frame. The schema is encoded in
+// full before it is written, so it saves no allocation over Marshal: unlike
+// json.Encoder, which would add a newline, the bytes are exactly Marshal's.
+func WriteTo(w io.Writer, s Schema) (int64, error) {
+	b, err := Marshal(s)
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(b)
+	return int64(n), err
 }
 
 // UnmarshalSchema unmarshals an encoded schema into a known schema type.
@@ -40,97 +101,110 @@ func UnmarshalSchema(b []byte, s Schema) error {
 	return json.Unmarshal(b, s)
 }
 
+// schemaString returns the JSON form of a schema for use by String methods.
+func schemaString(s Schema) string {
+	b, err := Marshal(s)
+	if err != nil {
+		return fmt.Sprintf("%%!v(avroschema: %s)", err)
+	}
+	return string(b)
+}
+
 // Unmarshal unmarshals an encoded schema into a schema value.
 func Unmarshal(b []byte) (Schema, error) {
-	b = bytes.TrimSpace(b)
+	return new(Parser).Parse(b)
+}
+
+// ParseString unmarshals a schema from its JSON text.
+func ParseString(s string) (Schema, error) {
+	return Unmarshal([]byte(s))
+}
 
-	// Nothing to do.
-	if len(b) == 0 {
-		return nil, nil
+// MustParse is like ParseString but panics if the schema cannot be parsed. It
+// simplifies declaring schemas in package-level variables and tests.
+func MustParse(s string) Schema {
+	x, err := ParseString(s)
+	if err != nil {
+		panic(err)
 	}
+	return x
+}
 
-	// Decode a schema value into its native type.
-	switch b[0] {
-	// String-based type, so this is a primitive.
-	case '"':
-		var s string
-		if err := json.Unmarshal(b, &s); err != nil {
-			return nil, err
-		}
+// UnmarshalValue builds a schema from a value already decoded from JSON, which
+// avoids encoding it again to call Unmarshal. See Parser.ParseValue.
+func UnmarshalValue(v interface{}) (Schema, error) {
+	return new(Parser).ParseValue(v)
+}
 
-		// This does not imply this is a valid primitive type.
-		return Primitive(s), nil
+// member is a single key-value pair of an object.
+type member struct {
+	key   string
+	value interface{}
+}
 
-		// Square bracket implies a union.
-	case '[':
-		var u Union
-		if err := json.Unmarshal(b, &u); err != nil {
-			return nil, err
+// object is a JSON object whose members are encoded in the order they are
+// listed, so schemas are marshaled with a stable key order matching the spec.
+type object []member
+
+// withProps appends custom attributes, in key order, that do not collide
+// with the existing members.
+func (o object) withProps(props map[string]interface{}) object {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		if !o.has(k) {
+			keys = append(keys, k)
 		}
+	}
+	sort.Strings(keys)
 
-		return u, nil
+	for _, k := range keys {
+		o = append(o, member{k, props[k]})
+	}
+	return o
+}
 
-		// Curly brace implies a complex or logical type.
-	case '{':
-		// Decode just enough to determine the type.
-		type structType struct {
-			Type        string `json:"type"`
-			LogicalType string `json:"logicalType"`
+func (o object) has(key string) bool {
+	for _, m := range o {
+		if m.key == key {
+			return true
 		}
+	}
+	return false
+}
 
-		var s structType
-		if err := json.Unmarshal(b, &s); err != nil {
-			return nil, err
-		}
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
 
-		var x Schema
-
-		// Check for logical types.
-		if s.LogicalType != "" {
-			switch s.LogicalType {
-			case "date":
-				x = Date
-			case "time-millis":
-				x = TimeMillis
-			case "time-micros":
-				x = TimeMicros
-			case "timestamp-millis":
-				x = TimestampMillis
-			case "timestamp-micros":
-				x = TimestampMicros
-			case "duration":
-				x = Duration
-			default:
-				return nil, fmt.Errorf("avroschema: unknown logical type %v", s.LogicalType)
-			}
-
-			return x, nil
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
 		}
 
-		// Check for complex type.
-		switch s.Type {
-		case "record":
-			x = &Record{}
-		case "enum":
-			x = &Enum{}
-		case "array":
-			x = &Array{}
-		case "map":
-			x = &Map{}
-		case "fixed":
-			x = &Fixed{}
+		k, err := json.Marshal(m.key)
+		if err != nil {
+			return nil, err
+		}
+		var v []byte
+		switch x := m.value.(type) {
+		case Schema:
+			v, err = marshalSchema(x)
+		case []Schema:
+			v, err = marshalSchemas(x)
 		default:
-			return nil, fmt.Errorf("avroschema: unknown complex type %v", s.Type)
+			v, err = json.Marshal(x)
 		}
-
-		if err := json.Unmarshal(b, x); err != nil {
+		if err != nil {
 			return nil, err
 		}
 
-		return x, nil
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
 	}
 
-	return nil, fmt.Errorf("avroschema: could not unmarshal %v as Schema", string(b))
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
 }
 
 // Schema models an Avro schema definition.
@@ -140,6 +214,22 @@ type Schema interface {
 	Type() string
 }
 
+// Named is implemented by the named types: records, enums and fixed types.
+type Named interface {
+	Schema
+
+	// Fullname returns the name qualified by the type's namespace, or the
+	// name alone if the namespace is empty or the name is already qualified.
+	Fullname() string
+}
+
+// IsNamed returns true if the schema is a named type or a resolved reference
+// to one.
+func IsNamed(s Schema) bool {
+	_, ok := deref(s).(Named)
+	return ok
+}
+
 // Contains returns true if the schema contains the member schema. In the case of
 // of a union, this will check if the member exists in the union. Otherwise it will
 // check if the member schema is equal to the source schema.
@@ -151,8 +241,27 @@ func Contains(s, m Schema) bool {
 	return Equal(s, m)
 }
 
-// Equal returns true if the two schema are equivalent.
+// Equal returns true if the two schema are equivalent. References are compared
+// by the schema they refer to where it is known, so a named type is equal to a
+// reference to it, and recursive schemas are compared without looping.
 func Equal(s1, s2 Schema) bool {
+	return equal(s1, s2, make(map[[2]*Record]bool))
+}
+
+// equal compares two schemas. Seen holds the pairs of records being compared,
+// which are assumed equal when reached again through a cycle.
+func equal(s1, s2 Schema, seen map[[2]*Record]bool) bool {
+	s1, s2 = deref(s1), deref(s2)
+
+	// Custom attributes are not compared, so an annotated primitive is equal
+	// to the primitive.
+	if a, ok := s1.(*AnnotatedPrimitive); ok {
+		s1 = a.Primitive
+	}
+	if a, ok := s2.(*AnnotatedPrimitive); ok {
+		s2 = a.Primitive
+	}
+
 	if s1.Type() != s2.Type() {
 		return false
 	}
@@ -169,30 +278,69 @@ func Equal(s1, s2 Schema) bool {
 		TimeMillis.Type(),
 		TimeMicros.Type(),
 		TimestampMillis.Type(),
-		TimestampMicros.Type(),
-		Duration.Type():
+		TimestampMicros.Type():
 
 		return true
 	}
 
 	switch x1 := s1.(type) {
 	case Union:
-		return x1.isEqual(s2)
+		return x1.isEqual(s2, seen)
 	case *Record:
-		return x1.isEqual(s2)
+		return x1.isEqual(s2, seen)
 	case *Enum:
 		return x1.isEqual(s2)
 	case *Map:
-		return x1.isEqual(s2)
+		return x1.isEqual(s2, seen)
 	case *Array:
+		return x1.isEqual(s2, seen)
+	case *Fixed:
 		return x1.isEqual(s2)
 	case *Decimal:
 		return x1.isEqual(s2)
+	case *annotatedDecimal:
+		return x1.isEqual(s2)
+	case *FixedDecimal:
+		return x1.isEqual(s2)
+	case *duration:
+		return x1.isEqual(s2)
+	case *Reference:
+		return x1.isEqual(s2)
+	case *UnknownLogical:
+		return x1.isEqual(s2)
+	}
+
+	// Custom logical types of the same name are equal if they annotate equal
+	// types.
+	if u1, u2 := Underlying(s1), Underlying(s2); u1 != s1 && u2 != s2 {
+		return equal(u1, u2, seen)
 	}
 
 	return false
 }
 
+// Underlying returns the schema a logical type is backed by, such as Int for
+// Date or Bytes for a decimal. Schemas that are not logical types are returned
+// as is.
+func Underlying(s Schema) Schema {
+	switch x := s.(type) {
+	case *date, *timeMillis:
+		return Int
+	case *timeMicros, *timestampMillis, *timestampMicros:
+		return Long
+	case *Decimal, *annotatedDecimal:
+		return Bytes
+	case *FixedDecimal:
+		return x.Fixed
+	case *duration:
+		return x.fixed()
+	case interface{ Underlying() Schema }:
+		return x.Underlying()
+	}
+
+	return s
+}
+
 // Primitive models an Avro primitive type.
 type Primitive string
 
@@ -209,6 +357,44 @@ func (p Primitive) isEqual(o Schema) bool {
 	return p == x
 }
 
+// AnnotatedPrimitive is a primitive type written in its object form with custom
+// attributes, such as {"type":"string","x-db-column":"name"}. Its Type is the
+// primitive's and Underlying returns the primitive, so it is treated as the
+// primitive everywhere but when marshaled.
+type AnnotatedPrimitive struct {
+	Primitive Primitive
+
+	// Props holds custom attributes that are not defined by the spec.
+	Props map[string]interface{}
+}
+
+func (a *AnnotatedPrimitive) Type() string {
+	return string(a.Primitive)
+}
+
+func (a *AnnotatedPrimitive) Underlying() Schema {
+	return a.Primitive
+}
+
+func (a *AnnotatedPrimitive) String() string {
+	return schemaString(a)
+}
+
+func (a *AnnotatedPrimitive) MarshalJSON() ([]byte, error) {
+	return json.Marshal(object{
+		{"type", a.Primitive},
+	}.withProps(a.Props))
+}
+
+// IsPrimitive returns true if the string names one of the Avro primitive types.
+func IsPrimitive(s string) bool {
+	switch Primitive(s) {
+	case Null, Boolean, Int, Long, Float, Double, Bytes, String:
+		return true
+	}
+	return false
+}
+
 type Field struct {
 	Name    string      `json:"name"`
 	Type    Schema      `json:"type"`
@@ -216,13 +402,39 @@ type Field struct {
 	Default interface{} `json:"default,omitempty"`
 	Aliases []string    `json:"aliases,omitempty"`
 	Order   string      `json:"order,omitempty"`
+
+	// Props holds custom attributes that are not defined by the spec.
+	Props map[string]interface{} `json:"-"`
+
+	// nullDefault is set when the field's default is explicitly null, which
+	// Default alone cannot distinguish from having no default.
+	nullDefault bool
+}
+
+// HasDefault returns true if the field defines a default, including null. A
+// parsed field keeps a null default distinct from an absent one, and a field
+// built by hand needs SetDefault to define a null default.
+func (f *Field) HasDefault() bool {
+	return f.Default != nil || f.nullDefault
+}
+
+// SetDefault defines the field's default, which may be nil for a null default.
+func (f *Field) SetDefault(v interface{}) {
+	f.Default = v
+	f.nullDefault = v == nil
+}
+
+// ClearDefault removes the field's default, including a null one.
+func (f *Field) ClearDefault() {
+	f.Default = nil
+	f.nullDefault = false
 }
 
-func (f *Field) isEqual(x *Field) bool {
+func (f *Field) isEqual(x *Field, seen map[[2]*Record]bool) bool {
 	if f.Name != x.Name {
 		return false
 	}
-	if !Equal(f.Type, x.Type) {
+	if !equal(f.Type, x.Type, seen) {
 		return false
 	}
 	// TODO: support aliases..
@@ -230,34 +442,53 @@ func (f *Field) isEqual(x *Field) bool {
 	return true
 }
 
-func (f *Field) UnmarshalJSON(b []byte) error {
-	type proxy struct {
-		Name    string          `json:"name"`
-		Type    json.RawMessage `json:"type"`
-		Doc     string          `json:"doc,omitempty"`
-		Default interface{}     `json:"default,omitempty"`
-		Aliases []string        `json:"aliases,omitempty"`
-		Order   string          `json:"order,omitempty"`
+// MarshalJSON encodes the field with its attributes in the order given by the spec.
+func (f *Field) MarshalJSON() ([]byte, error) {
+	if err := validateOrder(f); err != nil {
+		return nil, err
 	}
 
-	var p proxy
-	if err := json.Unmarshal(b, &p); err != nil {
-		return err
+	o := object{
+		{"name", f.Name},
+		{"type", f.Type},
 	}
 
-	f.Name = p.Name
-	f.Doc = p.Doc
-	f.Default = p.Default
-	f.Aliases = p.Aliases
-	f.Order = p.Order
+	if f.Doc != "" {
+		o = append(o, member{"doc", f.Doc})
+	}
+
+	if f.HasDefault() {
+		o = append(o, member{"default", f.Default})
+	}
+
+	if f.Order != "" {
+		o = append(o, member{"order", f.Order})
+	}
+
+	if len(f.Aliases) > 0 {
+		o = append(o, member{"aliases", f.Aliases})
+	}
+
+	o = o.withProps(f.Props)
+
+	return json.Marshal(o)
+}
 
-	t, err := Unmarshal(p.Type)
+func (f *Field) UnmarshalJSON(b []byte) error {
+	x, err := decodeObject(b)
 	if err != nil {
 		return err
 	}
-	f.Type = t
+	return newParser(&Parser{}).parseField(x, "", f)
+}
 
-	return nil
+// validateOrder checks the field's sort order is one of the values allowed by the spec.
+func validateOrder(f *Field) error {
+	switch f.Order {
+	case "", "ascending", "descending", "ignore":
+		return nil
+	}
+	return fmt.Errorf("avroschema: field %v has invalid order %v", f.Name, f.Order)
 }
 
 type Record struct {
@@ -266,20 +497,56 @@ type Record struct {
 	Doc       string
 	Aliases   []string
 	Fields    []*Field
+
+	// Error marks the record as an error declared by a protocol, which is
+	// marshaled with the type "error".
+	Error bool
+
+	// Props holds custom attributes that are not defined by the spec.
+	Props map[string]interface{}
+
+	// fieldIndex caches the position of each field by name. It is checked
+	// against Fields on every lookup, so Fields may be changed at any time.
+	fieldIndex nameIndex
+}
+
+// FieldIndex returns the position of the named field in the record.
+func (r *Record) FieldIndex(name string) (int, bool) {
+	return r.fieldIndex.lookup(name, len(r.Fields), func(i int) string {
+		return r.Fields[i].Name
+	})
 }
 
-func (r *Record) isEqual(o Schema) bool {
+// Field returns the named field of the record.
+func (r *Record) Field(name string) (*Field, bool) {
+	i, ok := r.FieldIndex(name)
+	if !ok {
+		return nil, false
+	}
+	return r.Fields[i], true
+}
+
+func (r *Record) isEqual(o Schema, seen map[[2]*Record]bool) bool {
 	x, ok := o.(*Record)
 	if !ok {
 		return false
 	}
 
+	key := [2]*Record{r, x}
+	if seen[key] {
+		return true
+	}
+	seen[key] = true
+
 	if r.Name != x.Name {
 		return false
 	}
 	if r.Namespace != x.Namespace {
 		return false
 	}
+	if r.Error != x.Error {
+		return false
+	}
 
 	if len(r.Fields) != len(x.Fields) {
 		return false
@@ -288,7 +555,7 @@ func (r *Record) isEqual(o Schema) bool {
 	// TODO: does equality require order?
 	for i, rf := range r.Fields {
 		xf := x.Fields[i]
-		if !rf.isEqual(xf) {
+		if !rf.isEqual(xf, seen) {
 			return false
 		}
 	}
@@ -296,30 +563,53 @@ func (r *Record) isEqual(o Schema) bool {
 	return true
 }
 
+func (r *Record) Fullname() string {
+	return fullname(r.Name, r.Namespace)
+}
+
 func (r *Record) Type() string {
 	return "record"
 }
 
+func (r *Record) String() string {
+	return schemaString(r)
+}
+
 func (r *Record) MarshalJSON() ([]byte, error) {
-	m := map[string]interface{}{
-		"type":   "record",
-		"name":   r.Name,
-		"fields": r.Fields,
+	typ := "record"
+	if r.Error {
+		typ = "error"
+	}
+
+	o := object{
+		{"type", typ},
+		{"name", r.Name},
 	}
 
 	if r.Namespace != "" {
-		m["namespace"] = r.Namespace
+		o = append(o, member{"namespace", r.Namespace})
 	}
 
 	if r.Doc != "" {
-		m["doc"] = r.Doc
+		o = append(o, member{"doc", r.Doc})
 	}
 
 	if len(r.Aliases) > 0 {
-		m["aliases"] = r.Aliases
+		o = append(o, member{"aliases", r.Aliases})
 	}
 
-	return json.Marshal(m)
+	o = append(o, member{"fields", r.Fields})
+	o = o.withProps(r.Props)
+
+	return json.Marshal(o)
+}
+
+func (r *Record) UnmarshalJSON(b []byte) error {
+	x, err := decodeObject(b)
+	if err != nil {
+		return err
+	}
+	return newParser(&Parser{}).parseRecord(x, "", r)
 }
 
 type Enum struct {
@@ -328,6 +618,32 @@ type Enum struct {
 	Doc       string
 	Aliases   []string
 	Symbols   []string
+
+	// Default is the symbol used when resolving a symbol the reader does not
+	// define. It is empty if the enum has no default.
+	Default string
+
+	// Props holds custom attributes that are not defined by the spec.
+	Props map[string]interface{}
+
+	// index caches the position of each symbol. It is checked against
+	// Symbols on every lookup, so Symbols may be changed at any time.
+	index nameIndex
+}
+
+// Index returns the position of the symbol in the enum.
+func (e *Enum) Index(symbol string) (int, bool) {
+	return e.index.lookup(symbol, len(e.Symbols), func(i int) string {
+		return e.Symbols[i]
+	})
+}
+
+// Symbol returns the symbol at the position in the enum.
+func (e *Enum) Symbol(index int) (string, bool) {
+	if index < 0 || index >= len(e.Symbols) {
+		return "", false
+	}
+	return e.Symbols[index], true
 }
 
 func (e *Enum) isEqual(o Schema) bool {
@@ -353,126 +669,147 @@ func (e *Enum) isEqual(o Schema) bool {
 		}
 	}
 
+	if e.Default != x.Default {
+		return false
+	}
+
 	return true
 }
 
+func (e *Enum) Fullname() string {
+	return fullname(e.Name, e.Namespace)
+}
+
 func (e *Enum) Type() string {
 	return "enum"
 }
 
+func (e *Enum) String() string {
+	return schemaString(e)
+}
+
 func (e *Enum) MarshalJSON() ([]byte, error) {
-	m := map[string]interface{}{
-		"type":    "enum",
-		"name":    e.Name,
-		"symbols": e.Symbols,
+	o := object{
+		{"type", "enum"},
+		{"name", e.Name},
 	}
 
 	if e.Namespace != "" {
-		m["namespace"] = e.Namespace
+		o = append(o, member{"namespace", e.Namespace})
 	}
 
 	if e.Doc != "" {
-		m["doc"] = e.Doc
+		o = append(o, member{"doc", e.Doc})
 	}
 
 	if len(e.Aliases) > 0 {
-		m["aliases"] = e.Aliases
+		o = append(o, member{"aliases", e.Aliases})
+	}
+
+	o = append(o, member{"symbols", e.Symbols})
+
+	if e.Default != "" {
+		o = append(o, member{"default", e.Default})
 	}
 
-	return json.Marshal(m)
+	o = o.withProps(e.Props)
+
+	return json.Marshal(o)
+}
+
+func (e *Enum) UnmarshalJSON(b []byte) error {
+	x, err := decodeObject(b)
+	if err != nil {
+		return err
+	}
+	return newParser(&Parser{}).parseEnum(x, "", e)
 }
 
 type Array struct {
 	Items Schema
+
+	// Props holds custom attributes that are not defined by the spec.
+	Props map[string]interface{}
 }
 
-func (a *Array) isEqual(o Schema) bool {
+func (a *Array) isEqual(o Schema, seen map[[2]*Record]bool) bool {
 	x, ok := o.(*Array)
 	if !ok {
 		return false
 	}
 
-	return Equal(a.Items, x.Items)
+	return equal(a.Items, x.Items, seen)
 }
 
 func (a *Array) Type() string {
 	return "array"
 }
 
-func (a *Array) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"type":  "array",
-		"items": a.Items,
-	})
+func (a *Array) String() string {
+	return schemaString(a)
 }
 
-func (a *Array) UnmarshalJSON(b []byte) error {
-	type proxy struct {
-		Type  string
-		Items json.RawMessage
+func (a *Array) MarshalJSON() ([]byte, error) {
+	o := object{
+		{"type", "array"},
+		{"items", a.Items},
 	}
 
-	var p proxy
-	if err := json.Unmarshal(b, &p); err != nil {
-		return err
-	}
+	return json.Marshal(o.withProps(a.Props))
+}
 
-	t, err := Unmarshal(p.Items)
+func (a *Array) UnmarshalJSON(b []byte) error {
+	x, err := decodeObject(b)
 	if err != nil {
 		return err
 	}
-
-	a.Items = t
-	return nil
+	return newParser(&Parser{}).parseArray(x, "", a)
 }
 
 type Map struct {
 	Values Schema
+
+	// Props holds custom attributes that are not defined by the spec.
+	Props map[string]interface{}
 }
 
-func (m *Map) isEqual(o Schema) bool {
+func (m *Map) isEqual(o Schema, seen map[[2]*Record]bool) bool {
 	x, ok := o.(*Map)
 	if !ok {
 		return false
 	}
 
-	return Equal(m.Values, x.Values)
+	return equal(m.Values, x.Values, seen)
 }
 
 func (m *Map) Type() string {
 	return "map"
 }
 
-func (m *Map) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"type":   "map",
-		"values": m.Values,
-	})
+func (m *Map) String() string {
+	return schemaString(m)
 }
 
-func (m *Map) UnmarshalJSON(b []byte) error {
-	type proxy struct {
-		Type   string
-		Values json.RawMessage
+func (m *Map) MarshalJSON() ([]byte, error) {
+	o := object{
+		{"type", "map"},
+		{"values", m.Values},
 	}
 
-	var p proxy
-	if err := json.Unmarshal(b, &p); err != nil {
-		return err
-	}
+	return json.Marshal(o.withProps(m.Props))
+}
 
-	t, err := Unmarshal(p.Values)
+func (m *Map) UnmarshalJSON(b []byte) error {
+	x, err := decodeObject(b)
 	if err != nil {
 		return err
 	}
-
-	m.Values = t
-	return nil
+	return newParser(&Parser{}).parseMap(x, "", m)
 }
 
 type Union []Schema
 
-func (u Union) isEqual(o Schema) bool {
+func (u Union) isEqual(o Schema, seen map[[2]*Record]bool) bool {
 	x, ok := o.(Union)
 	if !ok {
 		return false
@@ -483,7 +820,7 @@ func (u Union) isEqual(o Schema) bool {
 	}
 
 	for i, s := range u {
-		if !Equal(s, x[i]) {
+		if !equal(s, x[i], seen) {
 			return false
 		}
 	}
@@ -505,23 +842,26 @@ func (u Union) Type() string {
 	return "union"
 }
 
-func (u *Union) UnmarshalJSON(b []byte) error {
-	var p []json.RawMessage
-	if err := json.Unmarshal(b, &p); err != nil {
-		return err
-	}
+func (u Union) String() string {
+	return schemaString(u)
+}
 
-	x := make(Union, len(p))
-	for i, e := range p {
-		t, err := Unmarshal(e)
-		if err != nil {
-			return err
-		}
-		x[i] = t
+// MarshalJSON writes the union as an array of its branches, even if it has one
+// branch or none. An error is returned if the union is not valid, so that a
+// schema other implementations reject is not written.
+func (u Union) MarshalJSON() ([]byte, error) {
+	if err := validateUnion(u); err != nil {
+		return nil, err
 	}
+	return marshalSchemas(u)
+}
 
-	*u = x
-	return nil
+func (u *Union) UnmarshalJSON(b []byte) error {
+	var x []interface{}
+	if err := json.Unmarshal(b, &x); err != nil {
+		return err
+	}
+	return newParser(&Parser{}).parseUnion(x, "", u)
 }
 
 type Fixed struct {
@@ -529,6 +869,9 @@ type Fixed struct {
 	Namespace string
 	Size      int
 	Aliases   []string
+
+	// Props holds custom attributes that are not defined by the spec.
+	Props map[string]interface{}
 }
 
 func (f *Fixed) isEqual(o Schema) bool {
@@ -551,25 +894,80 @@ func (f *Fixed) isEqual(o Schema) bool {
 	return true
 }
 
+func (f *Fixed) Fullname() string {
+	return fullname(f.Name, f.Namespace)
+}
+
 func (f *Fixed) Type() string {
 	return "fixed"
 }
 
+func (f *Fixed) String() string {
+	return schemaString(f)
+}
+
 func (f *Fixed) MarshalJSON() ([]byte, error) {
-	m := map[string]interface{}{
-		"type": "fixed",
-		"name": f.Name,
+	return json.Marshal(f.object().withProps(f.Props))
+}
+
+// object returns the attributes of the fixed type defined by the spec, which
+// logical types annotating it follow with their own.
+func (f *Fixed) object() object {
+	o := object{
+		{"type", "fixed"},
+		{"name", f.Name},
 	}
 
 	if f.Namespace != "" {
-		m["namespace"] = f.Namespace
+		o = append(o, member{"namespace", f.Namespace})
 	}
 
 	if len(f.Aliases) > 0 {
-		m["aliases"] = f.Aliases
+		o = append(o, member{"aliases", f.Aliases})
+	}
+
+	return append(o, member{"size", f.Size})
+}
+
+func (f *Fixed) UnmarshalJSON(b []byte) error {
+	x, err := decodeObject(b)
+	if err != nil {
+		return err
+	}
+	return newParser(&Parser{}).parseFixed(x, "", f)
+}
+
+// Reference models the use of a named type (record, enum or fixed) that is
+// defined elsewhere in the schema. It marshals to the full name of the type.
+type Reference struct {
+	// Name is the full name of the referenced type.
+	Name string
+
+	// Schema is the definition being referenced, if known.
+	Schema Schema
+}
+
+func (r *Reference) isEqual(o Schema) bool {
+	x, ok := o.(*Reference)
+	if !ok {
+		return false
 	}
 
-	return json.Marshal(m)
+	return r.Name == x.Name
+}
+
+// Type returns the full name of the referenced type.
+func (r *Reference) Type() string {
+	return r.Name
+}
+
+// String returns the full name of the referenced type.
+func (r *Reference) String() string {
+	return r.Name
+}
+
+func (r *Reference) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Name)
 }
 
 type Decimal struct {
@@ -578,8 +976,8 @@ type Decimal struct {
 }
 
 func (d *Decimal) isEqual(o Schema) bool {
-	x, ok := o.(*Decimal)
-	if !ok {
+	x, f, ok := decimalOf(o)
+	if !ok || f != nil {
 		return false
 	}
 
@@ -590,90 +988,296 @@ func (d *Decimal) Type() string {
 	return "decimal"
 }
 
+func (d *Decimal) String() string {
+	return schemaString(d)
+}
+
 func (d *Decimal) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"type":        "bytes",
-		"logicalType": "decimal",
-		"precision":   d.Precision,
-		"scale":       d.Scale,
-	})
+	return json.Marshal(d.object())
+}
+
+func (d *Decimal) object() object {
+	return object{
+		{"type", "bytes"},
+		{"logicalType", "decimal"},
+		{"precision", d.Precision},
+		{"scale", d.Scale},
+	}
 }
 
-type date struct{}
+// annotatedDecimal is a decimal annotating bytes with custom attributes.
+type annotatedDecimal struct {
+	Decimal
+	Props map[string]interface{}
+}
+
+func (d *annotatedDecimal) String() string {
+	return schemaString(d)
+}
+
+func (d *annotatedDecimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.object().withProps(d.Props))
+}
+
+// FixedDecimal is a decimal annotating a fixed type, whose size bounds the
+// precision. References to the fixed type refer to the decimal.
+type FixedDecimal struct {
+	Precision int
+	Scale     int
+
+	// Fixed is the fixed type the decimal annotates, which holds its custom
+	// attributes.
+	Fixed *Fixed
+}
+
+func (d *FixedDecimal) isEqual(o Schema) bool {
+	x, f, ok := decimalOf(o)
+	if !ok || f == nil {
+		return false
+	}
+
+	return d.Precision == x.Precision && d.Scale == x.Scale && d.Fixed.isEqual(f)
+}
+
+func (d *FixedDecimal) Type() string {
+	return "decimal"
+}
+
+func (d *FixedDecimal) String() string {
+	return schemaString(d)
+}
+
+func (d *FixedDecimal) MarshalJSON() ([]byte, error) {
+	f := d.Fixed
+	o := f.object()
+	o = append(o,
+		member{"logicalType", "decimal"},
+		member{"precision", d.Precision},
+		member{"scale", d.Scale},
+	)
+	o = o.withProps(f.Props)
+
+	return json.Marshal(o)
+}
+
+// decimalOf returns the precision and scale of a decimal, with the fixed type
+// it annotates or nil if it annotates bytes.
+func decimalOf(s Schema) (*Decimal, *Fixed, bool) {
+	switch x := s.(type) {
+	case *Decimal:
+		return x, nil, true
+	case *annotatedDecimal:
+		return &x.Decimal, nil, true
+	case *FixedDecimal:
+		return &Decimal{x.Precision, x.Scale}, x.Fixed, true
+	}
+	return nil, nil, false
+}
+
+type date struct {
+	Props map[string]interface{}
+}
 
 func (d *date) Type() string {
 	return "date"
 }
 
+func (d *date) String() string {
+	return schemaString(d)
+}
+
 func (d *date) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"type":        "int",
-		"logicalType": "date",
-	})
+	return json.Marshal(object{
+		{"type", "int"},
+		{"logicalType", "date"},
+	}.withProps(d.Props))
 }
 
-type timeMillis struct{}
+type timeMillis struct {
+	Props map[string]interface{}
+}
 
 func (t *timeMillis) Type() string {
 	return "time-millis"
 }
 
+func (t *timeMillis) String() string {
+	return schemaString(t)
+}
+
 func (t *timeMillis) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"type":        "int",
-		"logicalType": "time-millis",
-	})
+	return json.Marshal(object{
+		{"type", "int"},
+		{"logicalType", "time-millis"},
+	}.withProps(t.Props))
 }
 
-type timeMicros struct{}
+type timeMicros struct {
+	Props map[string]interface{}
+}
 
 func (t *timeMicros) Type() string {
 	return "time-micros"
 }
 
+func (t *timeMicros) String() string {
+	return schemaString(t)
+}
+
 func (t *timeMicros) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"type":        "long",
-		"logicalType": "time-micros",
-	})
+	return json.Marshal(object{
+		{"type", "long"},
+		{"logicalType", "time-micros"},
+	}.withProps(t.Props))
 }
 
-type timestampMillis struct{}
+type timestampMillis struct {
+	Props map[string]interface{}
+}
 
 func (t *timestampMillis) Type() string {
 	return "timestamp-millis"
 }
 
+func (t *timestampMillis) String() string {
+	return schemaString(t)
+}
+
 func (t *timestampMillis) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"type":        "long",
-		"logicalType": "timestamp-millis",
-	})
+	return json.Marshal(object{
+		{"type", "long"},
+		{"logicalType", "timestamp-millis"},
+	}.withProps(t.Props))
 }
 
-type timestampMicros struct{}
+type timestampMicros struct {
+	Props map[string]interface{}
+}
 
 func (t *timestampMicros) Type() string {
 	return "timestamp-micros"
 }
 
+func (t *timestampMicros) String() string {
+	return schemaString(t)
+}
+
 func (t *timestampMicros) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"type":        "long",
-		"logicalType": "timestamp-micros",
-	})
+	return json.Marshal(object{
+		{"type", "long"},
+		{"logicalType", "timestamp-micros"},
+	}.withProps(t.Props))
 }
 
-type duration struct{}
+type duration struct {
+	// Fixed is the fixed type the duration annotates, which holds its custom
+	// attributes. The predefined Duration annotates a fixed named duration.
+	Fixed *Fixed
+}
+
+func (d *duration) fixed() *Fixed {
+	if d.Fixed != nil {
+		return d.Fixed
+	}
+	return &Fixed{Name: "duration", Size: 12}
+}
+
+func (d *duration) isEqual(o Schema) bool {
+	x, ok := o.(*duration)
+	if !ok {
+		return false
+	}
+
+	return d.fixed().isEqual(x.fixed())
+}
 
 func (d *duration) Type() string {
 	return "duration"
 }
 
+func (d *duration) String() string {
+	return schemaString(d)
+}
+
 func (d *duration) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"type":        "fixed",
-		"logicalType": "duration",
-		"size":        12,
-	})
+	f := d.fixed()
+	o := f.object()
+	o = append(o, member{"logicalType", "duration"})
+	o = o.withProps(f.Props)
+
+	return json.Marshal(o)
+}
+
+// UnknownLogical is a logical type annotating a primitive that is neither built
+// in nor registered, as kept by Parser.KeepUnknownLogicalTypes. Its Type is the
+// logical type's name and Underlying returns the primitive.
+type UnknownLogical struct {
+	Name    string
+	Backing Primitive
+
+	// Props holds the attributes of the logical type other than its name.
+	Props map[string]interface{}
+}
+
+func (u *UnknownLogical) isEqual(o Schema) bool {
+	x, ok := o.(*UnknownLogical)
+	if !ok {
+		return false
+	}
+
+	return u.Name == x.Name && u.Backing == x.Backing
+}
+
+func (u *UnknownLogical) Type() string {
+	return u.Name
+}
+
+func (u *UnknownLogical) Underlying() Schema {
+	return u.Backing
+}
+
+func (u *UnknownLogical) String() string {
+	return schemaString(u)
+}
+
+func (u *UnknownLogical) MarshalJSON() ([]byte, error) {
+	return json.Marshal(object{
+		{"type", u.Backing},
+		{"logicalType", u.Name},
+	}.withProps(u.Props))
+}
+
+// nameIndex caches the positions of the names in a list, such as the fields of
+// a record. A position found is checked against the list, and a name not found
+// is looked for in the list, which rebuilds the cache if the name is there, so
+// it stays correct when the list changes. Copies share the cache until one
+// rebuilds it.
+type nameIndex struct {
+	v atomic.Value // map[string]int
+}
+
+// lookup returns the position of the name in the list of n names given by at.
+func (x *nameIndex) lookup(name string, n int, at func(int) string) (int, bool) {
+	m, _ := x.v.Load().(map[string]int)
+	if i, ok := m[name]; ok && i < n && at(i) == name {
+		return i, true
+	}
+
+	found := false
+	for i := 0; i < n && !found; i++ {
+		found = at(i) == name
+	}
+	if !found && m != nil {
+		return 0, false
+	}
+
+	m = make(map[string]int, n)
+	for i := n - 1; i >= 0; i-- {
+		// Keep the first position if a name is duplicated.
+		m[at(i)] = i
+	}
+	x.v.Store(m)
+
+	i, ok := m[name]
+	return i, ok
 }
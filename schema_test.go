@@ -1,12 +1,46 @@
 package avro
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
+// cmpSchema are the options for comparing schema values. Fields are compared
+// with whether they have a default, which tells a null default from none,
+// while the lookup caches of Enum and Record are ignored.
+var cmpSchema = []cmp.Option{
+	cmp.Transformer("Field", func(f Field) fieldState {
+		return fieldState{
+			Name:       f.Name,
+			Type:       f.Type,
+			Doc:        f.Doc,
+			Default:    f.Default,
+			HasDefault: f.HasDefault(),
+			Aliases:    f.Aliases,
+			Order:      f.Order,
+			Props:      f.Props,
+		}
+	}),
+	cmpopts.IgnoreUnexported(Enum{}, Record{}),
+}
+
+// fieldState is the state of a Field that tests compare.
+type fieldState struct {
+	Name       string
+	Type       Schema
+	Doc        string
+	Default    interface{}
+	HasDefault bool
+	Aliases    []string
+	Order      string
+	Props      map[string]interface{}
+}
+
 func TestSchema(t *testing.T) {
 	r1 := &Record{
 		Name: "Record",
@@ -52,7 +86,7 @@ func TestSchema(t *testing.T) {
 	}
 
 	// Compare to ensure schema unmarshaling worked.
-	if diff := cmp.Diff(r1, &r2); diff != "" {
+	if diff := cmp.Diff(r1, &r2, cmpSchema...); diff != "" {
 		t.Errorf("(-want +got)\n%s", diff)
 	}
 }
@@ -78,8 +112,83 @@ func TestEqual(t *testing.T) {
 			B:     &Decimal{1, 3},
 			Equal: false,
 		},
+		{
+			A:     &Array{Items: &Decimal{Precision: 4, Scale: 2}},
+			B:     &Array{Items: &Decimal{Precision: 4, Scale: 2}},
+			Equal: true,
+		},
+		{
+			A:     &Array{Items: &Decimal{Precision: 4, Scale: 2}},
+			B:     &Array{Items: &Decimal{Precision: 5, Scale: 2}},
+			Equal: false,
+		},
+		{
+			A:     &Map{Values: &Decimal{Precision: 4, Scale: 2}},
+			B:     &Map{Values: &Decimal{Precision: 4, Scale: 3}},
+			Equal: false,
+		},
+		{
+			A:     &Decimal{Precision: 4, Scale: 2},
+			B:     &FixedDecimal{Precision: 4, Scale: 2, Fixed: &Fixed{Name: "money", Size: 4}},
+			Equal: false,
+		},
+		{
+			A:     &Array{Items: Date},
+			B:     &Array{Items: Date},
+			Equal: true,
+		},
+		{
+			A:     &Map{Values: TimestampMillis},
+			B:     &Map{Values: TimestampMillis},
+			Equal: true,
+		},
+		{
+			A:     &Array{Items: Date},
+			B:     &Array{Items: TimeMillis},
+			Equal: false,
+		},
+		{
+			A:     &Array{Items: Date},
+			B:     &Array{Items: Int},
+			Equal: false,
+		},
+		{
+			A:     &Array{Items: Date},
+			B:     &Array{Items: &date{Props: map[string]interface{}{"source": "legacy"}}},
+			Equal: true,
+		},
+		{
+			A:     &Array{Items: &Record{Name: "Node"}},
+			B:     &Array{Items: &Reference{Name: "Node", Schema: &Record{Name: "Node"}}},
+			Equal: true,
+		},
+		{
+			A:     &Map{Values: &Reference{Name: "Node"}},
+			B:     &Map{Values: &Reference{Name: "Node"}},
+			Equal: true,
+		},
+		{
+			A:     &Map{Values: &Reference{Name: "Node"}},
+			B:     &Map{Values: &Reference{Name: "Leaf"}},
+			Equal: false,
+		},
 	}
 
+	// Recursive records are compared without looping, whether they refer to
+	// themselves by pointer or by reference.
+	changed := newTree(false)
+	changed.Fields[0].Type = Long
+
+	tests = append(tests, []struct {
+		A     Schema
+		B     Schema
+		Equal bool
+	}{
+		{A: newTree(false), B: newTree(true), Equal: true},
+		{A: &Array{Items: newTree(true)}, B: &Array{Items: newTree(false)}, Equal: true},
+		{A: &Map{Values: newTree(true)}, B: &Map{Values: changed}, Equal: false},
+	}...)
+
 	for i, test := range tests {
 		t.Run(fmt.Sprint(i), func(t *testing.T) {
 			equal := Equal(test.A, test.B)
@@ -93,6 +202,60 @@ func TestEqual(t *testing.T) {
 	}
 }
 
+// newTree returns a recursive record whose children refer to it by pointer or,
+// if ref is set, by reference.
+func newTree(ref bool) *Record {
+	r := &Record{Name: "Tree"}
+
+	var self Schema = r
+	if ref {
+		self = &Reference{Name: "Tree", Schema: r}
+	}
+
+	r.Fields = []*Field{
+		{Name: "value", Type: Int},
+		{Name: "children", Type: &Array{Items: self}},
+		{Name: "index", Type: &Map{Values: self}},
+	}
+	return r
+}
+
+func TestNamed(t *testing.T) {
+	user := &Record{Name: "User", Namespace: "com.example"}
+
+	// Fullname is empty for schemas that do not implement Named. Resolved
+	// references do not, but IsNamed reports the type they refer to.
+	tests := []struct {
+		Schema   Schema
+		Fullname string
+		IsNamed  bool
+	}{
+		{user, "com.example.User", true},
+		{&Enum{Name: "Suit"}, "Suit", true},
+		{&Fixed{Name: "com.example.Hash", Namespace: "org.other", Size: 16}, "com.example.Hash", true},
+		{&Reference{Name: "com.example.User", Schema: user}, "", true},
+		{&Reference{Name: "com.example.User"}, "", false},
+		{String, "", false},
+		{&Array{Items: user}, "", false},
+		{Union{Null, user}, "", false},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			var fn string
+			if n, ok := test.Schema.(Named); ok {
+				fn = n.Fullname()
+			}
+			if fn != test.Fullname {
+				t.Errorf("expected full name %q, got %q", test.Fullname, fn)
+			}
+			if IsNamed(test.Schema) != test.IsNamed {
+				t.Errorf("expected IsNamed %v", test.IsNamed)
+			}
+		})
+	}
+}
+
 func TestUnionContains(t *testing.T) {
 	u := Union{
 		Null,
@@ -112,3 +275,1027 @@ func TestUnionContains(t *testing.T) {
 		t.Errorf("expected string")
 	}
 }
+
+func TestUnmarshalUnknownPrimitive(t *testing.T) {
+	if _, err := Unmarshal([]byte(`"flaot"`)); err == nil {
+		t.Errorf("expected error for unknown type")
+	}
+
+	s, err := Unmarshal([]byte(`"float"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != Float {
+		t.Errorf("expected float, got %v", s)
+	}
+}
+
+func TestUnmarshalReference(t *testing.T) {
+	b := []byte(`{
+		"type": "record",
+		"name": "LinkedList",
+		"namespace": "com.example",
+		"fields": [
+			{"name": "value", "type": "long"},
+			{"name": "next", "type": ["null", "LinkedList"]}
+		]
+	}`)
+
+	s, err := Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := s.(*Record)
+	ref := r.Fields[1].Type.(Union)[1].(*Reference)
+
+	if ref.Name != "com.example.LinkedList" {
+		t.Errorf("expected full name, got %v", ref.Name)
+	}
+	if ref.Schema != r {
+		t.Errorf("expected reference to resolve to the record")
+	}
+
+	// References marshal by name, so recursive schemas can be encoded.
+	if _, err := Marshal(s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnmarshalNamespaceInheritance(t *testing.T) {
+	b := []byte(`{
+		"type": "record",
+		"name": "Rec",
+		"namespace": "com.x",
+		"fields": [
+			{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B"]}},
+			{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 16}},
+			{"name": "other", "type": {"type": "fixed", "name": "org.y.Other", "size": 4}},
+			{"name": "again", "type": "com.x.Kind"}
+		]
+	}`)
+
+	s, err := Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := s.(*Record)
+
+	if e := r.Fields[0].Type.(*Enum); e.Namespace+"."+e.Name != "com.x.Kind" {
+		t.Errorf("expected enum com.x.Kind, got namespace %q name %q", e.Namespace, e.Name)
+	}
+	if f := r.Fields[1].Type.(*Fixed); f.Namespace != "com.x" {
+		t.Errorf("expected fixed namespace com.x, got %q", f.Namespace)
+	}
+	if f := r.Fields[2].Type.(*Fixed); f.Namespace != "" {
+		t.Errorf("expected full name to not inherit namespace, got %q", f.Namespace)
+	}
+}
+
+func TestUnmarshalVerbosePrimitive(t *testing.T) {
+	s, err := Unmarshal([]byte(`{"type": "int"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != Int {
+		t.Errorf("expected int, got %v", s)
+	}
+
+	// Nested positions accept the object form too.
+	s, err = Unmarshal([]byte(`{"type": "array", "items": {"type": "string"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Equal(s, &Array{Items: String}) {
+		t.Errorf("expected array of strings, got %v", s)
+	}
+}
+
+func TestProps(t *testing.T) {
+	b := []byte(`{
+		"type": "record",
+		"name": "User",
+		"x-table": "users",
+		"fields": [
+			{"name": "name", "type": "string", "x-db-column": "user_name"},
+			{"name": "hash", "type": {"type": "fixed", "name": "md5", "size": 16, "x-algo": "md5"}}
+		]
+	}`)
+
+	s, err := Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := s.(*Record)
+	if r.Props["x-table"] != "users" {
+		t.Errorf("expected record prop, got %v", r.Props)
+	}
+	if r.Fields[0].Props["x-db-column"] != "user_name" {
+		t.Errorf("expected field prop, got %v", r.Fields[0].Props)
+	}
+
+	// Props survive a round trip.
+	b, err = Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s2, err := Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(s, s2, cmpSchema...); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
+
+func TestPrimitiveProps(t *testing.T) {
+	in := `{"type":"string","x-db-column":"name"}`
+
+	s, err := Unmarshal([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := &AnnotatedPrimitive{Primitive: String, Props: map[string]interface{}{"x-db-column": "name"}}
+	if diff := cmp.Diff(exp, s); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+	if !Equal(s, String) || !Equal(String, s) {
+		t.Errorf("expected %v to equal string", s)
+	}
+	if Underlying(s) != String {
+		t.Errorf("expected underlying string, got %v", Underlying(s))
+	}
+
+	b, err := Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != in {
+		t.Errorf("expected %s, got %s", in, b)
+	}
+
+	// An ignored logical type is not kept as a custom attribute.
+	s, err = Unmarshal([]byte(`{"type":"string","logicalType":"date","x-db-column":"name"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(exp, s); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+
+	p := Parser{DisallowUnknownFields: true}
+	if _, err := p.Parse([]byte(in)); err == nil {
+		t.Errorf("expected error for unknown attribute")
+	}
+}
+
+func TestFieldMarshal(t *testing.T) {
+	f1 := &Field{
+		Name:    "created",
+		Type:    Long,
+		Doc:     "Time the row was created.",
+		Order:   "descending",
+		Aliases: []string{"created_at"},
+	}
+
+	b, err := json.Marshal(f1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := `{"name":"created","type":"long","doc":"Time the row was created.","order":"descending","aliases":["created_at"]}`
+	if string(b) != exp {
+		t.Errorf("expected %s, got %s", exp, b)
+	}
+
+	var f2 Field
+	if err := json.Unmarshal(b, &f2); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(f1, &f2, cmpSchema...); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+
+	// Invalid sort orders are rejected in both directions.
+	if _, err := json.Marshal(&Field{Name: "x", Type: Long, Order: "desc"}); err == nil {
+		t.Errorf("expected marshal error for invalid order")
+	}
+	if err := json.Unmarshal([]byte(`{"name":"x","type":"long","order":"desc"}`), &f2); err == nil {
+		t.Errorf("expected unmarshal error for invalid order")
+	}
+}
+
+func TestUnmarshalLogicalBacking(t *testing.T) {
+	tests := []struct {
+		Schema  string
+		Lenient Schema
+	}{
+		{`{"type": "int", "logicalType": "date"}`, Date},
+		{`{"type": "long", "logicalType": "timestamp-millis"}`, TimestampMillis},
+		{`{"type": "bytes", "logicalType": "decimal", "precision": 4, "scale": 2}`, &Decimal{4, 2}},
+		{`{"type": "string", "logicalType": "date"}`, String},
+		{`{"type": "int", "logicalType": "time-micros"}`, Int},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			s, err := Unmarshal([]byte(test.Schema))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !Equal(s, test.Lenient) {
+				t.Errorf("expected %v, got %v", test.Lenient, s)
+			}
+
+			// Strict parsing rejects the mismatched cases.
+			p := Parser{StrictLogicalTypes: true}
+			_, err = p.Parse([]byte(test.Schema))
+			if _, ok := test.Lenient.(Primitive); ok && err == nil {
+				t.Errorf("expected strict error")
+			} else if !ok && err != nil {
+				t.Errorf("unexpected strict error: %s", err)
+			}
+		})
+	}
+}
+
+func TestUnderlying(t *testing.T) {
+	tests := []struct {
+		Schema     Schema
+		Underlying Schema
+	}{
+		{Date, Int},
+		{TimeMillis, Int},
+		{TimeMicros, Long},
+		{TimestampMillis, Long},
+		{TimestampMicros, Long},
+		{&Decimal{4, 2}, Bytes},
+		{&FixedDecimal{Precision: 4, Scale: 2, Fixed: &Fixed{Name: "money", Size: 4}}, &Fixed{Name: "money", Size: 4}},
+		{Duration, &Fixed{Name: "duration", Size: 12}},
+		{String, String},
+		{&Array{Items: Date}, &Array{Items: Date}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			if u := Underlying(test.Schema); !Equal(u, test.Underlying) {
+				t.Errorf("expected %v, got %v", test.Underlying, u)
+			}
+		})
+	}
+}
+
+func TestLogicalProps(t *testing.T) {
+	tests := []string{
+		`{"type":"bytes","logicalType":"decimal","precision":4,"scale":2,"java-class":"java.math.BigDecimal"}`,
+		`{"type":"int","logicalType":"date","precision":4,"source":"legacy"}`,
+		`{"type":"long","logicalType":"timestamp-millis","connect.name":"Timestamp"}`,
+		`{"type":"fixed","name":"Interval","size":12,"logicalType":"duration","unit":"iso8601"}`,
+	}
+
+	for i, in := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			s, err := Unmarshal([]byte(in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := Marshal(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != in {
+				t.Errorf("expected %s, got %s", in, b)
+			}
+		})
+	}
+
+	// The predefined instances are used when there are no other attributes.
+	if s, _ := Unmarshal([]byte(`{"type":"int","logicalType":"date"}`)); s != Date {
+		t.Errorf("expected Date, got %#v", s)
+	}
+}
+
+func TestUnmarshalFixedDecimal(t *testing.T) {
+	in := `{"type":"record","name":"Payment","fields":[` +
+		`{"name":"amount","type":{"type":"fixed","name":"money","size":8,"logicalType":"decimal","precision":18,"scale":2,"currency":"EUR"}},` +
+		`{"name":"fee","type":"money"}]}`
+
+	s, err := Unmarshal([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := &FixedDecimal{
+		Precision: 18,
+		Scale:     2,
+		Fixed: &Fixed{
+			Name:  "money",
+			Size:  8,
+			Props: map[string]interface{}{"currency": "EUR"},
+		},
+	}
+
+	r := s.(*Record)
+	if !Equal(r.Fields[0].Type, exp) {
+		t.Errorf("expected %v, got %v", exp, r.Fields[0].Type)
+	}
+
+	// References to the fixed type resolve to the decimal.
+	if ref := r.Fields[1].Type.(*Reference); ref.Schema != r.Fields[0].Type {
+		t.Errorf("expected reference to the decimal, got %v", ref.Schema)
+	}
+
+	b, err := Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != in {
+		t.Errorf("expected %s, got %s", in, b)
+	}
+}
+
+// uuid is a custom logical type used to test registration.
+type uuid struct{}
+
+func (u *uuid) Type() string {
+	return "uuid"
+}
+
+func (u *uuid) Underlying() Schema {
+	return String
+}
+
+func init() {
+	RegisterLogicalType("uuid", func(backing Schema, attrs map[string]interface{}) (Schema, error) {
+		if backing != String {
+			return nil, fmt.Errorf("uuid must annotate string")
+		}
+		return &uuid{}, nil
+	})
+}
+
+func TestUnmarshalDuration(t *testing.T) {
+	in := `{"type":"record","name":"Session","namespace":"a.b","fields":[` +
+		`{"name":"length","type":{"type":"fixed","name":"Dur","aliases":["Span"],"size":12,"logicalType":"duration"}},` +
+		`{"name":"idle","type":"Dur"}]}`
+
+	s, err := Unmarshal([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := s.(*Record)
+	exp := &duration{Fixed: &Fixed{Name: "Dur", Namespace: "a.b", Aliases: []string{"Span"}, Size: 12}}
+	if diff := cmp.Diff(exp, r.Fields[0].Type); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+	if d := deref(r.Fields[1].Type); d != r.Fields[0].Type {
+		t.Errorf("expected reference to the duration, got %v", d)
+	}
+	if err := Validate(s); err != nil {
+		t.Error(err)
+	}
+
+	b, err := Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, err := Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Equal(s, s2) {
+		t.Errorf("expected %v, got %v", s, s2)
+	}
+
+	// A duration must have size 12.
+	bad := `{"type":"fixed","name":"Dur","size":10,"logicalType":"duration"}`
+	s, err = Unmarshal([]byte(bad))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.(*Fixed); !ok {
+		t.Errorf("expected fixed, got %v", s)
+	}
+	p := Parser{StrictLogicalTypes: true}
+	if _, err := p.Parse([]byte(bad)); err == nil {
+		t.Errorf("expected error for size 10")
+	}
+	if err := Validate(&duration{Fixed: &Fixed{Name: "Dur", Size: 10}}); err == nil {
+		t.Errorf("expected error for size 10")
+	}
+}
+
+func TestRegisterLogicalType(t *testing.T) {
+	s, err := Unmarshal([]byte(`{"type": "string", "logicalType": "uuid"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.(*uuid); !ok {
+		t.Fatalf("expected uuid, got %v", s)
+	}
+	if u := Underlying(s); u != String {
+		t.Errorf("expected string, got %v", u)
+	}
+
+	// Custom logical types are equal by name and the type they annotate, and
+	// are marshaled as the type they annotate.
+	if !Equal(s, s) || !Equal(s, &uuid{}) || Equal(s, String) {
+		t.Errorf("expected %v to equal uuid only", s)
+	}
+	in := `{"type":"record","name":"User","fields":[{"name":"id","type":{"type":"string","logicalType":"uuid"}},` +
+		`{"name":"ids","type":{"type":"array","items":["null",{"type":"string","logicalType":"uuid"}]}}]}`
+	s, err = Unmarshal([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != in {
+		t.Errorf("expected %s, got %s", in, b)
+	}
+	s2, err := Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Equal(s, s2) {
+		t.Errorf("expected %v, got %v", s, s2)
+	}
+
+	// Errors from the factory are returned.
+	if _, err := Unmarshal([]byte(`{"type": "int", "logicalType": "uuid"}`)); err == nil {
+		t.Errorf("expected factory error")
+	}
+
+	// Unregistered logical types decode as the type they annotate.
+	s, err = Unmarshal([]byte(`{"type": "long", "logicalType": "made-up"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != Long {
+		t.Errorf("expected long, got %v", s)
+	}
+}
+
+func TestKeepUnknownLogicalTypes(t *testing.T) {
+	in := `{"type":"record","name":"Event","fields":[` +
+		`{"name":"id","type":{"type":"string","logicalType":"made-up","version":2}},` +
+		`{"name":"hash","type":{"type":"fixed","name":"Hash","size":16,"logicalType":"made-up"}}]}`
+
+	p := Parser{KeepUnknownLogicalTypes: true}
+	s, err := p.Parse([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := s.(*Record).Fields[0].Type
+	exp := &UnknownLogical{Name: "made-up", Backing: String}
+	if !Equal(id, exp) {
+		t.Errorf("expected %v, got %v", exp, id)
+	}
+	if u := Underlying(id); u != String {
+		t.Errorf("expected string, got %v", u)
+	}
+
+	b, err := Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != in {
+		t.Errorf("expected %s, got %s", in, b)
+	}
+
+	// By default the annotation on the primitive is dropped.
+	s, err = Unmarshal([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := s.(*Record).Fields[0].Type; id != String {
+		t.Errorf("expected string, got %v", id)
+	}
+}
+
+func TestParserNames(t *testing.T) {
+	user := &Record{
+		Name:      "User",
+		Namespace: "com.example",
+		Fields:    []*Field{{Name: "id", Type: Long}},
+	}
+	p := Parser{Names: map[string]Schema{"com.example.User": user}}
+
+	// A schema that is only a name is the named type.
+	s, err := p.Parse([]byte(`"com.example.User"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != user {
+		t.Errorf("expected %v, got %v", user, s)
+	}
+
+	// Names are resolved in the enclosing namespace.
+	s, err = p.Parse([]byte(`{"type":"record","name":"Team","namespace":"com.example","fields":[` +
+		`{"name":"members","type":{"type":"array","items":"User"}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := s.(*Record).Fields[0].Type.(*Array).Items
+	if exp := (&Reference{Name: "com.example.User", Schema: user}); !Equal(items, exp) {
+		t.Errorf("expected %v, got %v", exp, items)
+	}
+
+	_, err = p.Parse([]byte(`"com.example.Group"`))
+	checkError(t, err, "com.example.Group is neither a primitive type nor a known named type")
+
+	_, err = Unmarshal([]byte(`"com.example.User"`))
+	checkError(t, err, "com.example.User is neither a primitive type nor a known named type")
+}
+
+func TestEnumIndex(t *testing.T) {
+	e := &Enum{
+		Name:    "suit",
+		Symbols: []string{"SPADES", "HEARTS", "DIAMONDS", "CLUBS"},
+	}
+
+	if i, ok := e.Index("DIAMONDS"); !ok || i != 2 {
+		t.Errorf("expected 2, got %v", i)
+	}
+	if _, ok := e.Index("JOKER"); ok {
+		t.Errorf("expected unknown symbol")
+	}
+
+	if s, ok := e.Symbol(3); !ok || s != "CLUBS" {
+		t.Errorf("expected CLUBS, got %v", s)
+	}
+	if _, ok := e.Symbol(4); ok {
+		t.Errorf("expected index out of range")
+	}
+	if _, ok := e.Symbol(-1); ok {
+		t.Errorf("expected index out of range")
+	}
+
+	// Symbols added later are found, and a copy is independent.
+	e.Symbols = append(e.Symbols, "JOKER")
+	if i, ok := e.Index("JOKER"); !ok || i != 4 {
+		t.Errorf("expected 4, got %v", i)
+	}
+	if err := Conforms(e, "JOKER"); err != nil {
+		t.Error(err)
+	}
+	c := *e
+	c.Symbols = []string{"JOKER"}
+	if i, _ := c.Index("JOKER"); i != 0 {
+		t.Errorf("expected 0, got %v", i)
+	}
+	if i, _ := e.Index("JOKER"); i != 4 {
+		t.Errorf("expected 4, got %v", i)
+	}
+
+	// Symbols renamed in place are found where they are.
+	e.Symbols[0], e.Symbols[3] = "CLUBS", "SPADES"
+	if i, ok := e.Index("SPADES"); !ok || i != 3 {
+		t.Errorf("expected 3, got %v", i)
+	}
+	if i, ok := e.Index("CLUBS"); !ok || i != 0 {
+		t.Errorf("expected 0, got %v", i)
+	}
+}
+
+func TestFieldDefault(t *testing.T) {
+	tests := []struct {
+		Default    string
+		HasDefault bool
+		Value      interface{}
+	}{
+		{`,"default":null`, true, nil},
+		{`,"default":false`, true, false},
+		{``, false, nil},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			in := `{"name":"f","type":["null","boolean"]` + test.Default + `}`
+
+			var f Field
+			if err := json.Unmarshal([]byte(in), &f); err != nil {
+				t.Fatal(err)
+			}
+			if f.HasDefault() != test.HasDefault || f.Default != test.Value {
+				t.Errorf("expected default %v (%v), got %v (%v)", test.Value, test.HasDefault, f.Default, f.HasDefault())
+			}
+
+			b, err := json.Marshal(&f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != in {
+				t.Errorf("expected %s, got %s", in, b)
+			}
+		})
+	}
+
+	// A field built by hand needs SetDefault for a null default.
+	f := &Field{Name: "f", Type: Union{Null, Boolean}}
+	if f.HasDefault() {
+		t.Errorf("expected no default")
+	}
+	f.SetDefault(nil)
+	if !f.HasDefault() {
+		t.Errorf("expected a null default")
+	}
+	f.ClearDefault()
+	if f.HasDefault() {
+		t.Errorf("expected no default after clearing it")
+	}
+}
+
+func TestRecordField(t *testing.T) {
+	r := &Record{
+		Name: "point",
+		Fields: []*Field{
+			{Name: "x", Type: Double},
+			{Name: "y", Type: Double},
+			{Name: "label", Type: String},
+		},
+	}
+
+	if i, ok := r.FieldIndex("y"); !ok || i != 1 {
+		t.Errorf("expected 1, got %v", i)
+	}
+	if f, ok := r.Field("label"); !ok || f != r.Fields[2] {
+		t.Errorf("expected label field, got %v", f)
+	}
+	if _, ok := r.Field("z"); ok {
+		t.Errorf("expected unknown field")
+	}
+	if _, ok := r.FieldIndex("z"); ok {
+		t.Errorf("expected unknown field")
+	}
+
+	// Fields added later are found, and a copy is independent.
+	r.Fields = append(r.Fields, &Field{Name: "z", Type: Double})
+	if i, ok := r.FieldIndex("z"); !ok || i != 3 {
+		t.Errorf("expected 3, got %v", i)
+	}
+	c := *r
+	c.Fields = c.Fields[2:]
+	if i, _ := c.FieldIndex("z"); i != 1 {
+		t.Errorf("expected 1, got %v", i)
+	}
+	if i, _ := r.FieldIndex("z"); i != 3 {
+		t.Errorf("expected 3, got %v", i)
+	}
+
+	// Fields renamed or moved in place are found where they are.
+	r.Fields[0] = &Field{Name: "w", Type: Double}
+	if i, ok := r.FieldIndex("w"); !ok || i != 0 {
+		t.Errorf("expected 0, got %v", i)
+	}
+	if _, ok := r.FieldIndex("x"); ok {
+		t.Errorf("expected unknown field")
+	}
+	r.Fields[1], r.Fields[3] = r.Fields[3], r.Fields[1]
+	if i, ok := r.FieldIndex("y"); !ok || i != 3 {
+		t.Errorf("expected 3, got %v", i)
+	}
+}
+
+func TestMarshalStable(t *testing.T) {
+	s := &Record{
+		Name:      "User",
+		Namespace: "com.example",
+		Doc:       "A user.",
+		Fields: []*Field{
+			{Name: "id", Type: &Fixed{Name: "uuid", Size: 16}},
+			{Name: "tags", Type: &Map{Values: &Array{Items: String}}},
+			{Name: "dob", Type: Union{Null, Date}},
+		},
+		Props: map[string]interface{}{
+			"z-owner": "growth",
+			"a-table": "users",
+		},
+	}
+
+	exp := `{"type":"record","name":"User","namespace":"com.example","doc":"A user.",` +
+		`"fields":[{"name":"id","type":{"type":"fixed","name":"uuid","size":16}},` +
+		`{"name":"tags","type":{"type":"map","values":{"type":"array","items":"string"}}},` +
+		`{"name":"dob","type":["null",{"type":"int","logicalType":"date"}]}],` +
+		`"a-table":"users","z-owner":"growth"}`
+
+	for i := 0; i < 10; i++ {
+		b, err := Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != exp {
+			t.Fatalf("expected %s, got %s", exp, b)
+		}
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	s := &Record{
+		Name: "Point",
+		Fields: []*Field{
+			{Name: "x", Type: Double},
+			{Name: "y", Type: Double},
+		},
+	}
+
+	var buf bytes.Buffer
+	n, err := WriteTo(&buf, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), b) {
+		t.Errorf("expected %s, got %s", b, buf.Bytes())
+	}
+	if n != int64(len(b)) {
+		t.Errorf("expected %d bytes, got %d", len(b), n)
+	}
+}
+
+func TestFromMap(t *testing.T) {
+	m := map[string]interface{}{
+		"type":      "record",
+		"name":      "Order",
+		"namespace": "com.example",
+		"fields": []interface{}{
+			map[string]interface{}{
+				"name": "status",
+				"type": map[string]interface{}{
+					"type":    "enum",
+					"name":    "Status",
+					"symbols": []interface{}{"OPEN", "CLOSED"},
+				},
+			},
+			map[string]interface{}{
+				"name":    "previous",
+				"type":    []interface{}{"null", "Status"},
+				"default": nil,
+			},
+			map[string]interface{}{
+				"name": "amount",
+				"type": map[string]interface{}{
+					"type":        "bytes",
+					"logicalType": "decimal",
+					"precision":   float64(9),
+					"scale":       float64(2),
+				},
+			},
+		},
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp, err := Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := FromMap(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := cmp.Diff(exp, s, cmpSchema...); d != "" {
+		t.Errorf("unexpected schema:\n%s", d)
+	}
+
+	// Errors are the same as those of the byte parser.
+	m["fields"] = []interface{}{
+		map[string]interface{}{"name": "a", "type": "Missing"},
+	}
+	_, err = FromMap(m)
+	checkError(t, err, "avroschema: unknown type Missing")
+}
+
+func TestMustParse(t *testing.T) {
+	s := MustParse(`{"type": "array", "items": "string"}`)
+	if exp := (&Array{Items: String}); !Equal(s, exp) {
+		t.Errorf("expected %v, got %v", exp, s)
+	}
+
+	if _, err := ParseString(`"Missing"`); err == nil {
+		t.Errorf("expected error")
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+	MustParse(`"Missing"`)
+}
+
+func TestUnmarshalValue(t *testing.T) {
+	var doc struct {
+		Schemas []interface{}   `json:"schemas"`
+		Raw     json.RawMessage `json:"raw"`
+	}
+	err := json.Unmarshal([]byte(`{
+		"schemas": [
+			"long",
+			["null", "string"],
+			{"type": "array", "items": {"type": "fixed", "name": "Id", "size": 4}}
+		],
+		"raw": {"type": "map", "values": "int"}
+	}`), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []Schema{
+		Long,
+		Union{Null, String},
+		&Array{Items: &Fixed{Name: "Id", Size: 4}},
+	}
+	for i, v := range doc.Schemas {
+		s, err := UnmarshalValue(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Equal(s, exp[i]) {
+			t.Errorf("expected %v, got %v", exp[i], s)
+		}
+	}
+
+	s, err := UnmarshalValue(doc.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := (&Map{Values: Int}); !Equal(s, exp) {
+		t.Errorf("expected %v, got %v", exp, s)
+	}
+
+	_, err = UnmarshalValue(float64(1))
+	checkError(t, err, "could not unmarshal 1 as Schema")
+}
+
+func TestString(t *testing.T) {
+	r := &Record{
+		Name: "Point",
+		Fields: []*Field{
+			{Name: "x", Type: Int},
+			{Name: "label", Type: Union{Null, String}},
+		},
+	}
+
+	tests := []struct {
+		Schema Schema
+		Exp    string
+	}{
+		{Int, `int`},
+		{Date, `{"type":"int","logicalType":"date"}`},
+		{Union{Null, Long}, `["null","long"]`},
+		{&Array{Items: String}, `{"type":"array","items":"string"}`},
+		{&Reference{Name: "com.example.Point", Schema: r}, `com.example.Point`},
+		{r, `{"type":"record","name":"Point","fields":[{"name":"x","type":"int"},{"name":"label","type":["null","string"]}]}`},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			if s := fmt.Sprintf("%s", test.Schema); s != test.Exp {
+				t.Errorf("expected %s, got %s", test.Exp, s)
+			}
+		})
+	}
+}
+
+func TestEnumDefault(t *testing.T) {
+	b := []byte(`{"type":"enum","name":"Suit","symbols":["SPADES","HEARTS","UNKNOWN"],"default":"UNKNOWN"}`)
+
+	s, err := Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := s.(*Enum)
+	if e.Default != "UNKNOWN" {
+		t.Errorf("expected default UNKNOWN, got %q", e.Default)
+	}
+	if e.Props != nil {
+		t.Errorf("expected default to not be a prop, got %v", e.Props)
+	}
+
+	out, err := Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != string(b) {
+		t.Errorf("expected %s, got %s", b, out)
+	}
+
+	if Equal(e, &Enum{Name: "Suit", Symbols: e.Symbols}) {
+		t.Errorf("expected enums with different defaults to not be equal")
+	}
+}
+
+func TestFieldMarshalDefaults(t *testing.T) {
+	b := []byte(`{"type":"record","name":"Flags","fields":[` +
+		`{"name":"count","type":"int","doc":"Times seen.","default":0,"order":"ignore","aliases":["n"]},` +
+		`{"name":"enabled","type":"boolean","default":false},` +
+		`{"name":"label","type":"string","default":""},` +
+		`{"name":"parent","type":["null","string"],"default":null},` +
+		`{"name":"required","type":"string"}]}`)
+
+	s, err := Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Zero and null defaults are kept, and keys are written in spec order.
+	out, err := Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != string(b) {
+		t.Errorf("expected %s, got %s", b, out)
+	}
+}
+
+func TestUnmarshalTrailingData(t *testing.T) {
+	tests := []string{
+		`"int" "long"`,
+		`{"type": "int"}{"type": "int"}`,
+		`{"type": "array", "items": "int"}]`,
+		`["null", "int"] x`,
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			if _, err := Unmarshal([]byte(test)); err == nil {
+				t.Errorf("expected error for trailing data")
+			}
+		})
+	}
+
+	// Surrounding whitespace is allowed.
+	if _, err := Unmarshal([]byte("\n\t\"int\" \n")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDisallowUnknownFields(t *testing.T) {
+	tests := []struct {
+		Schema string
+		Error  string
+	}{
+		{
+			Schema: `{"type": "record", "name": "R", "feilds": [{"name": "a", "type": "int"}]}`,
+			Error:  `unknown attribute "feilds" in record R`,
+		},
+		{
+			Schema: `{"type": "record", "name": "R", "fields": [{"name": "a", "tpye": "int", "type": "int"}]}`,
+			Error:  `unknown attribute "tpye" in field a`,
+		},
+		{
+			Schema: `{"type": "enum", "name": "E", "symbols": ["A"], "defualt": "A"}`,
+			Error:  `unknown attribute "defualt" in enum E`,
+		},
+		{
+			Schema: `{"type": "array", "item": "int", "items": "int"}`,
+			Error:  `unknown attribute "item" in array`,
+		},
+		{
+			Schema: `{"type": "int", "x-prop": 1}`,
+			Error:  `unknown attribute "x-prop" in int`,
+		},
+		{
+			// Logical types may define their own attributes.
+			Schema: `{"type": "fixed", "name": "id", "size": 16, "logicalType": "custom-id", "version": 4}`,
+		},
+		{
+			Schema: `{"type": "bytes", "logicalType": "decimal", "precision": 4, "scale": 2}`,
+		},
+	}
+
+	p := Parser{DisallowUnknownFields: true}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			_, err := p.Parse([]byte(test.Schema))
+			checkError(t, err, test.Error)
+
+			// The default is to keep unknown attributes.
+			if _, err := Unmarshal([]byte(test.Schema)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package avro
+
+import "reflect"
+
+// EncodedSize returns the number of bytes the Avro binary encoding of the value
+// takes. Arrays and maps are sized as a single block of all their items
+// followed by the terminating empty block. An error is returned if the value
+// does not conform to the schema.
+func EncodedSize(s Schema, v interface{}) (int, error) {
+	if err := checkValue(s, v); err != nil {
+		return 0, err
+	}
+	return encodedSize(s, v), nil
+}
+
+// encodedSize sizes a value already known to conform to the schema.
+func encodedSize(s Schema, v interface{}) int {
+	switch x := s.(type) {
+	case Primitive:
+		switch x {
+		case Null:
+			return 0
+		case Boolean:
+			return 1
+		case Int, Long:
+			n, _ := toInt64(v)
+			return longSize(n)
+		case Float:
+			return 4
+		case Double:
+			return 8
+		case Bytes:
+			return bytesSize(len(v.([]byte)))
+		case String:
+			return bytesSize(len(v.(string)))
+		}
+
+	case *Record:
+		m := v.(map[string]interface{})
+		n := 0
+		for _, f := range x.Fields {
+			n += encodedSize(f.Type, m[f.Name])
+		}
+		return n
+
+	case *Enum:
+		i, _ := x.Index(v.(string))
+		return longSize(int64(i))
+
+	case *Array:
+		// Nil and empty arrays are both the terminating block alone.
+		if v == nil {
+			return 1
+		}
+		rv := reflect.ValueOf(v)
+		if rv.Len() == 0 {
+			return 1
+		}
+		n := longSize(int64(rv.Len())) + 1
+		for i := 0; i < rv.Len(); i++ {
+			n += encodedSize(x.Items, rv.Index(i).Interface())
+		}
+		return n
+
+	case *Map:
+		if v == nil {
+			return 1
+		}
+		rv := reflect.ValueOf(v)
+		if rv.Len() == 0 {
+			return 1
+		}
+		n := longSize(int64(rv.Len())) + 1
+		for _, k := range rv.MapKeys() {
+			n += bytesSize(k.Len())
+			n += encodedSize(x.Values, rv.MapIndex(k).Interface())
+		}
+		return n
+
+	case Union:
+		i, b, _ := resolveBranch(x, v)
+		return longSize(int64(i)) + encodedSize(b, unionValue(v))
+
+	case *Fixed:
+		return x.Size
+
+	case *Reference:
+		return encodedSize(x.Schema, v)
+
+	case *Decimal, *annotatedDecimal, *FixedDecimal:
+		// Big numbers are encoded as the bytes of their unscaled value.
+		b, _ := decimalBytes(x, v)
+		return encodedSize(Underlying(s), b)
+	}
+
+	return encodedSize(Underlying(s), v)
+}
+
+// longSize returns the length of the zig-zag variable-length encoding of n.
+func longSize(n int64) int {
+	u := uint64(n<<1) ^ uint64(n>>63)
+	size := 1
+	for u >= 0x80 {
+		u >>= 7
+		size++
+	}
+	return size
+}
+
+// bytesSize returns the length of a bytes or string value of length n,
+// including its length prefix.
+func bytesSize(n int) int {
+	return longSize(int64(n)) + n
+}
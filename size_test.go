@@ -0,0 +1,153 @@
+package avro
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestEncodedSize(t *testing.T) {
+	record := &Record{
+		Name: "test",
+		Fields: []*Field{
+			{Name: "a", Type: Long},
+			{Name: "b", Type: String},
+		},
+	}
+
+	// Expected sizes are the lengths of the encodings given in the spec.
+	tests := []struct {
+		Schema Schema
+		Value  interface{}
+		Size   int
+	}{
+		{Null, nil, 0},
+		{Boolean, true, 1},
+		{Int, int32(0), 1},
+		{Int, int32(-64), 1},
+		{Int, int32(64), 2},
+		{Int, int32(math.MaxInt32), 5},
+		{Long, int64(math.MaxInt64), 10},
+		{Long, int64(math.MinInt64), 10},
+		{Float, float32(1.5), 4},
+		{Double, 1.5, 8},
+		{String, "foo", 4},
+		{Bytes, []byte{}, 1},
+		{record, map[string]interface{}{"a": int64(27), "b": "foo"}, 5},
+		{&Enum{Name: "e", Symbols: []string{"A", "B"}}, "B", 1},
+		{&Array{Items: Long}, []interface{}{int64(3), int64(27)}, 4},
+		{&Array{Items: Long}, []int64{}, 1},
+		{&Map{Values: Long}, map[string]interface{}{"a": int64(1)}, 5},
+		{Union{Null, String}, nil, 1},
+		{Union{Null, String}, "a", 3},
+		{&Fixed{Name: "md5", Size: 16}, make([]byte, 16), 16},
+		{Date, int32(17000), 3},
+		{&Decimal{Precision: 9, Scale: 2}, []byte{0x30, 0x39}, 3},
+		{&Decimal{Precision: 9, Scale: 2}, big.NewRat(12345, 100), 3},
+		{&Decimal{Precision: 9, Scale: 2}, big.NewInt(-1), 2},
+		{&Decimal{Precision: 38, Scale: 0}, maxDecimal38, 17},
+		{&Decimal{Precision: 38, Scale: 0}, new(big.Int).Neg(maxDecimal38), 17},
+		{&FixedDecimal{Precision: 38, Scale: 2, Fixed: &Fixed{Name: "money", Size: 16}}, big.NewInt(1), 16},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			n, err := EncodedSize(test.Schema, test.Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != test.Size {
+				t.Errorf("expected %d, got %d", test.Size, n)
+			}
+		})
+	}
+}
+
+// maxDecimal38 is the largest 38-digit number, whose two's complement takes 16
+// bytes.
+var maxDecimal38, _ = new(big.Int).SetString("99999999999999999999999999999999999999", 10)
+
+func TestEncodedSizeInvalid(t *testing.T) {
+	tests := []struct {
+		Schema Schema
+		Value  interface{}
+	}{
+		{Int, int64(math.MaxInt32 + 1)},
+		{String, 1},
+		{&Fixed{Name: "md5", Size: 16}, make([]byte, 4)},
+		{&Enum{Name: "e", Symbols: []string{"A"}}, "B"},
+		{Union{Int, Long}, 1},
+		{Union{Null, String}, true},
+		{Bytes, big.NewInt(1)},
+		{&Decimal{Precision: 4, Scale: 2}, big.NewRat(1, 1000)},
+		{&FixedDecimal{Precision: 4, Scale: 2, Fixed: &Fixed{Name: "F", Size: 1}}, big.NewRat(9999, 100)},
+		{&Decimal{Precision: 4, Scale: 2}, (*big.Int)(nil)},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			if _, err := EncodedSize(test.Schema, test.Value); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestEncodedSizeUnionValue(t *testing.T) {
+	u := Union{Bytes, &Fixed{Name: "f", Size: 2}}
+
+	// Two bytes can represent either branch, so the branch must be given.
+	if _, err := EncodedSize(u, []byte("ab")); err == nil {
+		t.Errorf("expected ambiguous union error")
+	}
+
+	n, err := EncodedSize(u, UnionValue{Index: 1, Value: []byte("ab")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3, got %d", n)
+	}
+
+	if _, err := EncodedSize(u, UnionValue{Index: 2, Value: []byte("ab")}); err == nil {
+		t.Errorf("expected out of range error")
+	}
+}
+
+func TestEncodedSizeNilCollections(t *testing.T) {
+	r := &Record{
+		Name: "R",
+		Fields: []*Field{
+			{Name: "tags", Type: &Array{Items: String}},
+			{Name: "attrs", Type: &Map{Values: Long}},
+			{Name: "note", Type: Union{Null, &Array{Items: String}}},
+		},
+	}
+
+	// Nil collections are empty, except as a union value where nil selects
+	// the null branch.
+	values := []map[string]interface{}{
+		{"tags": nil, "attrs": nil, "note": nil},
+		{"tags": []string(nil), "attrs": map[string]int64(nil), "note": nil},
+		{"tags": []interface{}{}, "attrs": map[string]interface{}{}, "note": nil},
+	}
+
+	for i, v := range values {
+		n, err := EncodedSize(r, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 3 {
+			t.Errorf("%d: expected 3, got %d", i, n)
+		}
+	}
+
+	i, _, err := r.Fields[2].Type.(Union).Resolve(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i != 0 {
+		t.Errorf("expected null branch, got %d", i)
+	}
+}
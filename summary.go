@@ -0,0 +1,109 @@
+package avro
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Summary renders the shape of the schema on one line for log lines and error
+// messages, such as
+//
+//	record User{id:string, dob:union[null,date], tags:array[string]}
+//
+// Named types are written by their unqualified name, in full where they are
+// first reached and by name alone thereafter. Enums list their symbols and
+// fixed types their size, logical types are written by their name, and docs,
+// defaults, aliases and custom attributes are left out. Use Marshal for the
+// full schema.
+func Summary(s Schema) string {
+	w := summaryWriter{seen: make(map[string]bool)}
+	w.write(s)
+	return w.buf.String()
+}
+
+type summaryWriter struct {
+	buf  strings.Builder
+	seen map[string]bool
+}
+
+func (w *summaryWriter) write(s Schema) {
+	switch x := s.(type) {
+	case Primitive:
+		w.buf.WriteString(string(x))
+
+	case *Record:
+		if w.named(x) {
+			return
+		}
+		w.buf.WriteString("{")
+		for i, f := range x.Fields {
+			if i > 0 {
+				w.buf.WriteString(", ")
+			}
+			w.buf.WriteString(f.Name)
+			w.buf.WriteString(":")
+			w.write(f.Type)
+		}
+		w.buf.WriteString("}")
+
+	case *Enum:
+		if w.named(x) {
+			return
+		}
+		fmt.Fprintf(&w.buf, "{%v}", strings.Join(x.Symbols, ","))
+
+	case *Fixed:
+		if w.named(x) {
+			return
+		}
+		fmt.Fprintf(&w.buf, "(%d)", x.Size)
+
+	case *Array:
+		w.buf.WriteString("array[")
+		w.write(x.Items)
+		w.buf.WriteString("]")
+
+	case *Map:
+		w.buf.WriteString("map[")
+		w.write(x.Values)
+		w.buf.WriteString("]")
+
+	case Union:
+		w.buf.WriteString("union[")
+		for i, t := range x {
+			if i > 0 {
+				w.buf.WriteString(",")
+			}
+			w.write(t)
+		}
+		w.buf.WriteString("]")
+
+	case *Reference:
+		if x.Schema != nil && !w.seen[x.Name] {
+			w.write(x.Schema)
+			return
+		}
+		w.buf.WriteString(shortName(x.Name))
+
+	case *Decimal, *annotatedDecimal, *FixedDecimal:
+		d, _, _ := decimalOf(x)
+		fmt.Fprintf(&w.buf, "decimal(%d,%d)", d.Precision, d.Scale)
+
+	default:
+		w.buf.WriteString(s.Type())
+	}
+}
+
+// named writes the kind and name of a named type, reporting true if it has
+// already been written in full so that only its name is written.
+func (w *summaryWriter) named(n Named) bool {
+	fn := n.Fullname()
+	if w.seen[fn] {
+		w.buf.WriteString(shortName(fn))
+		return true
+	}
+	w.seen[fn] = true
+
+	fmt.Fprintf(&w.buf, "%v %v", n.Type(), shortName(fn))
+	return false
+}
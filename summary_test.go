@@ -0,0 +1,42 @@
+package avro
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSummary(t *testing.T) {
+	user := MustParse(`{"type": "record", "name": "User", "namespace": "com.example", "doc": "A user.", "fields": [
+		{"name": "id", "type": "string"},
+		{"name": "dob", "type": ["null", {"type": "int", "logicalType": "date"}], "default": null},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "role", "type": {"type": "enum", "name": "Role", "symbols": ["ADMIN", "USER"]}},
+		{"name": "roles", "type": {"type": "map", "values": "Role"}},
+		{"name": "friends", "type": {"type": "array", "items": "User"}}
+	]}`)
+
+	tests := []struct {
+		Schema  Schema
+		Summary string
+	}{
+		{Long, "long"},
+		{Union{Null, &Decimal{Precision: 9, Scale: 2}}, "union[null,decimal(9,2)]"},
+		{&Map{Values: &Fixed{Name: "x.MD5", Size: 16}}, "map[fixed MD5(16)]"},
+		{TimestampMillis, "timestamp-millis"},
+		{
+			user,
+			"record User{id:string, dob:union[null,date], tags:array[string], " +
+				"role:enum Role{ADMIN,USER}, roles:map[Role], friends:array[User]}",
+		},
+		{newTree(false), "record Tree{value:int, children:array[Tree], index:map[Tree]}"},
+		{&Reference{Name: "com.example.Missing"}, "Missing"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			if s := Summary(test.Schema); s != test.Summary {
+				t.Errorf("expected %s, got %s", test.Summary, s)
+			}
+		})
+	}
+}
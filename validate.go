@@ -0,0 +1,297 @@
+package avro
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// nameRe matches the names and enum symbols allowed by the spec.
+var nameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Validate checks the schema and its members for definitions the spec does not
+// allow but which are not caught by the type system. Field defaults must be
+// valid values of the field's type, within its range, as DefaultValue decodes
+// them. Aliases must not make names ambiguous: a field alias must not be the
+// name or alias of another field of the record, and the full alias of a named
+// type must not be the full name or alias of another named type.
+func Validate(s Schema) error {
+	if err := validate(s); err != nil {
+		return err
+	}
+	return validateTypeAliases(s)
+}
+
+// validate checks the schema and its members, except for the aliases of named
+// types, which are checked across the whole schema.
+func validate(s Schema) error {
+	switch x := s.(type) {
+	case *Record:
+		if err := validateFieldAliases(x); err != nil {
+			return err
+		}
+		for _, f := range x.Fields {
+			if err := validate(f.Type); err != nil {
+				return err
+			}
+			if !f.HasDefault() {
+				continue
+			}
+			if _, err := DefaultValue(f); err != nil {
+				return fmt.Errorf("avroschema: record %v: %s", x.Name, strings.TrimPrefix(err.Error(), "avroschema: "))
+			}
+		}
+
+	case *Enum:
+		return validateEnum(x)
+
+	case *Fixed:
+		return validateFixed(x)
+
+	case *Decimal:
+		return validateDecimal(x)
+
+	case *annotatedDecimal:
+		return validateDecimal(&x.Decimal)
+
+	case *FixedDecimal:
+		if err := validateFixed(x.Fixed); err != nil {
+			return err
+		}
+		return validateFixedDecimal(x)
+
+	case *duration:
+		if err := validateFixed(x.fixed()); err != nil {
+			return err
+		}
+		return validateDuration(x)
+
+	case *Array:
+		return validate(x.Items)
+
+	case *Map:
+		return validate(x.Values)
+
+	case Union:
+		if err := validateUnion(x); err != nil {
+			return err
+		}
+		for _, t := range x {
+			if err := validate(t); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateEnum checks the symbols are well-formed and unique, and that the
+// default, if any, is one of them.
+func validateEnum(e *Enum) error {
+	seen := make(map[string]struct{}, len(e.Symbols))
+
+	for _, s := range e.Symbols {
+		if !nameRe.MatchString(s) {
+			return fmt.Errorf("avroschema: enum %v has invalid symbol %q", e.Name, s)
+		}
+		if _, ok := seen[s]; ok {
+			return fmt.Errorf("avroschema: enum %v has duplicate symbol %q", e.Name, s)
+		}
+		seen[s] = struct{}{}
+	}
+
+	if _, ok := seen[e.Default]; e.Default != "" && !ok {
+		return fmt.Errorf("avroschema: enum %v default %q is not a symbol", e.Name, e.Default)
+	}
+
+	return nil
+}
+
+// validateFieldAliases checks that no field alias is the name or alias of
+// another field of the record.
+func validateFieldAliases(r *Record) error {
+	owners := make(map[string]string, len(r.Fields))
+	for _, f := range r.Fields {
+		owners[f.Name] = f.Name
+	}
+
+	for _, f := range r.Fields {
+		for _, a := range f.Aliases {
+			if a == f.Name {
+				continue
+			}
+			if owner, ok := owners[a]; ok && owner != f.Name {
+				if owner == a {
+					return fmt.Errorf("avroschema: record %v field %v has alias %v, which is the name of another field", r.Name, f.Name, a)
+				}
+				return fmt.Errorf("avroschema: record %v fields %v and %v share the alias %v", r.Name, owner, f.Name, a)
+			}
+			owners[a] = f.Name
+		}
+	}
+	return nil
+}
+
+// validateTypeAliases checks that no full alias of a named type within the
+// schema is the full name or alias of another named type.
+func validateTypeAliases(s Schema) error {
+	var types []namedType
+	collectTypes(s, "", make(map[Schema]bool), &types)
+
+	owners := make(map[string]string, len(types))
+	for _, t := range types {
+		owners[t.fullname] = t.fullname
+	}
+
+	for _, t := range types {
+		for _, a := range t.aliases {
+			if a == t.fullname {
+				continue
+			}
+			if owner, ok := owners[a]; ok && owner != t.fullname {
+				if owner == a {
+					return fmt.Errorf("avroschema: type %v has alias %v, which is the name of another type", t.fullname, a)
+				}
+				return fmt.Errorf("avroschema: types %v and %v share the alias %v", owner, t.fullname, a)
+			}
+			owners[a] = t.fullname
+		}
+	}
+	return nil
+}
+
+// namedType is a named type with its full name and full aliases, resolved in
+// the namespace in effect where it is defined.
+type namedType struct {
+	schema   Schema
+	fullname string
+	aliases  []string
+}
+
+// collectTypes appends the named types defined within the schema to types, in
+// the order they are reached. Seen holds the types already collected.
+func collectTypes(s Schema, enclosing string, seen map[Schema]bool, types *[]namedType) {
+	var name, namespace string
+	var aliases []string
+
+	switch x := s.(type) {
+	case *Record:
+		name, namespace, aliases = x.Name, x.Namespace, x.Aliases
+	case *Enum:
+		name, namespace, aliases = x.Name, x.Namespace, x.Aliases
+	case *Fixed:
+		name, namespace, aliases = x.Name, x.Namespace, x.Aliases
+
+	case *Array:
+		collectTypes(x.Items, enclosing, seen, types)
+		return
+	case *Map:
+		collectTypes(x.Values, enclosing, seen, types)
+		return
+	case Union:
+		for _, t := range x {
+			collectTypes(t, enclosing, seen, types)
+		}
+		return
+	case *Reference:
+		if x.Schema != nil {
+			collectTypes(x.Schema, namespaceOf(fullname(x.Name, enclosing)), seen, types)
+		}
+		return
+	case *FixedDecimal:
+		collectTypes(x.Fixed, enclosing, seen, types)
+		return
+	case *duration:
+		if x.Fixed != nil {
+			collectTypes(x.Fixed, enclosing, seen, types)
+		}
+		return
+	default:
+		return
+	}
+
+	if seen[s] {
+		return
+	}
+	seen[s] = true
+
+	name, namespace = splitName(name, namespace, enclosing)
+	*types = append(*types, namedType{
+		schema:   s,
+		fullname: fullname(name, namespace),
+		aliases:  fullAliases(aliases, namespace),
+	})
+
+	if r, ok := s.(*Record); ok {
+		for _, f := range r.Fields {
+			collectTypes(f.Type, namespace, seen, types)
+		}
+	}
+}
+
+// validateUnion checks the union does not directly contain another union or
+// two branches of the same type. Named types are distinguished by full name,
+// and logical types by the type they annotate.
+func validateUnion(u Union) error {
+	seen := make(map[string]bool, len(u))
+	for _, s := range u {
+		if _, ok := s.(Union); ok {
+			return fmt.Errorf("avroschema: union %v contains a union", unionTypes(u))
+		}
+		name := branchName(s)
+		if seen[name] {
+			return fmt.Errorf("avroschema: union %v has more than one %v branch", unionTypes(u), name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// validateFixed checks the size is not negative.
+func validateFixed(f *Fixed) error {
+	if f.Size < 0 {
+		return fmt.Errorf("avroschema: fixed %v size %d must not be negative", f.Name, f.Size)
+	}
+	return nil
+}
+
+// validateDecimal checks the precision is positive and the scale is between
+// zero and the precision.
+func validateDecimal(d *Decimal) error {
+	if d.Precision <= 0 {
+		return fmt.Errorf("avroschema: decimal precision %d must be positive", d.Precision)
+	}
+	if d.Scale < 0 || d.Scale > d.Precision {
+		return fmt.Errorf("avroschema: decimal scale %d must be between 0 and the precision %d", d.Scale, d.Precision)
+	}
+	return nil
+}
+
+// validateFixedDecimal checks the decimal is valid and its precision fits in
+// the fixed size.
+func validateFixedDecimal(d *FixedDecimal) error {
+	if err := validateDecimal(&Decimal{d.Precision, d.Scale}); err != nil {
+		return err
+	}
+	if max := maxDecimalDigits(d.Fixed.Size); d.Precision > max {
+		return fmt.Errorf("avroschema: decimal precision %d does not fit in fixed %v of size %d, which holds at most %d digits", d.Precision, d.Fixed.Name, d.Fixed.Size, max)
+	}
+	return nil
+}
+
+// validateDuration checks the fixed type a duration annotates has size 12, for
+// the months, days and milliseconds it holds.
+func validateDuration(d *duration) error {
+	if f := d.fixed(); f.Size != 12 {
+		return fmt.Errorf("avroschema: duration %v must have size 12, not %d", f.Name, f.Size)
+	}
+	return nil
+}
+
+// maxDecimalDigits returns the number of decimal digits a two's-complement
+// integer of the given number of bytes can always hold.
+func maxDecimalDigits(size int) int {
+	return int(math.Floor(math.Log10(2) * float64(8*size-1)))
+}
@@ -0,0 +1,303 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestValidateEnum(t *testing.T) {
+	tests := []struct {
+		Symbols []string
+		Default string
+		Error   string
+	}{
+		{
+			Symbols: []string{"A", "B", "_c1"},
+		},
+		{
+			Symbols: []string{"A", "B", "A", "B"},
+			Error:   `duplicate symbol "A"`,
+		},
+		{
+			Symbols: []string{"A", "not valid", "two-words"},
+			Error:   `invalid symbol "not valid"`,
+		},
+		{
+			Symbols: []string{"1st"},
+			Error:   `invalid symbol "1st"`,
+		},
+		{
+			Symbols: []string{"A", "B", "UNKNOWN"},
+			Default: "UNKNOWN",
+		},
+		{
+			Symbols: []string{"A", "B"},
+			Default: "C",
+			Error:   `default "C" is not a symbol`,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			e := &Enum{Name: "e", Symbols: test.Symbols, Default: test.Default}
+
+			// Check both the schema value and its parsed form.
+			err := Validate(&Array{Items: e})
+			checkError(t, err, test.Error)
+
+			b, _ := json.Marshal(e)
+			_, err = Unmarshal(b)
+			checkError(t, err, test.Error)
+		})
+	}
+}
+
+func TestValidateDecimal(t *testing.T) {
+	tests := []struct {
+		Precision int
+		Scale     int
+		Size      int
+		Error     string
+	}{
+		{Precision: 9, Scale: 2},
+		{Precision: 4, Scale: 4},
+		{Precision: 4, Scale: 0},
+		{Precision: 0, Scale: 0, Error: "precision 0 must be positive"},
+		{Precision: 2, Scale: 3, Error: "scale 3 must be between 0 and the precision 2"},
+		{Precision: 2, Scale: -1, Error: "scale -1 must be between 0 and the precision 2"},
+		{Precision: 18, Scale: 2, Size: 8},
+		{Precision: 19, Scale: 2, Size: 8, Error: "precision 19 does not fit in fixed money of size 8"},
+		{Precision: 2, Scale: 0, Size: 1},
+		{Precision: 3, Scale: 0, Size: 1, Error: "precision 3 does not fit in fixed money of size 1"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			var d Schema = &Decimal{Precision: test.Precision, Scale: test.Scale}
+			if test.Size > 0 {
+				d = &FixedDecimal{Precision: test.Precision, Scale: test.Scale, Fixed: &Fixed{Name: "money", Size: test.Size}}
+			}
+			checkError(t, Validate(&Map{Values: d}), test.Error)
+
+			b, _ := json.Marshal(d)
+
+			// Invalid decimals are an error when strict and their underlying
+			// type otherwise.
+			p := Parser{StrictLogicalTypes: true}
+			_, err := p.Parse(b)
+			checkError(t, err, test.Error)
+
+			s, err := Unmarshal(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if u := Underlying(d); test.Error != "" && s.Type() != u.Type() {
+				t.Errorf("expected %v, got %v", u.Type(), s)
+			}
+		})
+	}
+}
+
+// checkError fails the test if the error does not contain the expected
+// message, or if an error occurred when none was expected.
+func checkError(t *testing.T, err error, exp string) {
+	t.Helper()
+
+	if exp == "" {
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+		return
+	}
+
+	if err == nil {
+		t.Errorf("expected error containing %q", exp)
+	} else if !strings.Contains(err.Error(), exp) {
+		t.Errorf("expected error containing %q, got %q", exp, err)
+	}
+}
+
+func TestValidateDefaults(t *testing.T) {
+	tests := []struct {
+		Schema string
+		Error  string
+	}{
+		{
+			Schema: `{"type": "record", "name": "R", "fields": [{"name": "n", "type": "int", "default": 2147483647}]}`,
+		},
+		{
+			Schema: `{"type": "record", "name": "R", "fields": [{"name": "n", "type": "int", "default": 3000000000}]}`,
+			Error:  "record R: invalid default for field n: value 3000000000 of type json.Number cannot represent int",
+		},
+		{
+			Schema: `{"type": "record", "name": "R", "fields": [{"name": "n", "type": "long", "default": 9223372036854775808}]}`,
+			Error:  "invalid default for field n: value 9223372036854775808 of type json.Number cannot represent long",
+		},
+		{
+			Schema: `{"type": "record", "name": "R", "fields": [{"name": "f", "type": "float", "default": 1e39}]}`,
+			Error:  "invalid default for field f: value 1e39 of type json.Number cannot represent float",
+		},
+		{
+			Schema: `{"type": "record", "name": "R", "fields": [{"name": "b", "type": "bytes", "default": "Ā"}]}`,
+			Error:  "invalid default for field b: bytes value contains code point U+0100 outside U+0000 to U+00FF",
+		},
+		{
+			Schema: `{"type": "record", "name": "R", "fields": [{"name": "e", "type": ` +
+				`{"type": "enum", "name": "E", "symbols": ["A", "B"]}, "default": "C"}]}`,
+			Error: `record R: invalid default for field e: "C" is not a symbol of enum E`,
+		},
+		{
+			Schema: `{"type": "record", "name": "R", "fields": [` +
+				`{"name": "e", "type": {"type": "enum", "name": "E", "symbols": ["A", "B"]}, "default": "A"},` +
+				`{"name": "f", "type": "E", "default": "b"}]}`,
+			Error: `record R: invalid default for field f: "b" is not a symbol of enum E`,
+		},
+		{
+			Schema: `{"type": "record", "name": "R", "fields": [{"name": "inner", "type": ` +
+				`{"type": "record", "name": "Inner", "fields": [{"name": "n", "type": "int", "default": 1.5}]}}]}`,
+			Error: "record Inner: invalid default for field n",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			s, err := Unmarshal([]byte(test.Schema))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkError(t, Validate(s), test.Error)
+		})
+	}
+}
+
+func TestValidateFixedSize(t *testing.T) {
+	tests := []struct {
+		Parser Parser
+		Size   int
+		Error  string
+	}{
+		{Size: 16},
+		{Size: 0},
+		{Size: -1, Error: "fixed f size -1 must not be negative"},
+		{Size: DefaultMaxFixedSize},
+		{Size: DefaultMaxFixedSize + 1, Error: "fixed f size 1048577 exceeds the limit of 1048576"},
+		{Parser: Parser{MaxFixedSize: 8}, Size: 16, Error: "fixed f size 16 exceeds the limit of 8"},
+		{Parser: Parser{MaxFixedSize: -1}, Size: 1 << 30},
+		{Parser: Parser{MaxFixedSize: -1}, Size: -1, Error: "must not be negative"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			_, err := test.Parser.Parse([]byte(fmt.Sprintf(`{"type": "fixed", "name": "f", "size": %d}`, test.Size)))
+			checkError(t, err, test.Error)
+		})
+	}
+
+	err := Validate(&Array{Items: &Fixed{Name: "f", Size: -4}})
+	checkError(t, err, "fixed f size -4 must not be negative")
+}
+
+func TestValidateUnion(t *testing.T) {
+	tests := []struct {
+		Union Union
+		Error string
+	}{
+		{Union: Union{Null, Int, &Fixed{Name: "a.F", Size: 1}, &Fixed{Name: "b.F", Size: 1}}},
+		{Union: Union{String}},
+		{Union: Union{}},
+		{Union: Union{Int, Null, Int}, Error: "union [int null int] has more than one int branch"},
+		{Union: Union{Int, Date}, Error: "union [int date] has more than one int branch"},
+		{Union: Union{&Record{Name: "R"}, &Reference{Name: "R"}}, Error: "more than one R branch"},
+		{Union: Union{Null, Union{Int}}, Error: "union [null union] contains a union"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			checkError(t, Validate(test.Union), test.Error)
+
+			// Invalid unions are not marshaled, even within another type.
+			_, err := Marshal(&Array{Items: test.Union})
+			checkError(t, err, test.Error)
+		})
+	}
+}
+
+func TestMarshalUnion(t *testing.T) {
+	tests := []struct {
+		Union Union
+		JSON  string
+	}{
+		{Union{String}, `["string"]`},
+		{Union{}, `[]`},
+		{nil, `[]`},
+	}
+
+	for _, test := range tests {
+		b, err := Marshal(test.Union)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != test.JSON {
+			t.Errorf("expected %s, got %s", test.JSON, b)
+		}
+	}
+}
+
+func TestValidateAliases(t *testing.T) {
+	tests := []struct {
+		Schema string
+		Error  string
+	}{
+		{
+			Schema: `{"type": "record", "name": "R", "fields": [
+				{"name": "a", "type": "int", "aliases": ["old_a", "a"]},
+				{"name": "b", "type": "int", "aliases": ["old_b"]}
+			]}`,
+		},
+		{
+			Schema: `{"type": "record", "name": "R", "fields": [
+				{"name": "a", "type": "int"},
+				{"name": "b", "type": "int", "aliases": ["a"]}
+			]}`,
+			Error: "record R field b has alias a, which is the name of another field",
+		},
+		{
+			Schema: `{"type": "record", "name": "R", "fields": [
+				{"name": "a", "type": "int", "aliases": ["old"]},
+				{"name": "b", "type": "int", "aliases": ["old"]}
+			]}`,
+			Error: "record R fields a and b share the alias old",
+		},
+		{
+			// Aliases in different namespaces do not collide.
+			Schema: `{"type": "record", "name": "R", "namespace": "x", "aliases": ["Old"], "fields": [
+				{"name": "e", "type": {"type": "enum", "name": "y.E", "aliases": ["Old"], "symbols": ["A"]}}
+			]}`,
+		},
+		{
+			Schema: `{"type": "record", "name": "R", "namespace": "x", "fields": [
+				{"name": "e", "type": {"type": "enum", "name": "E", "aliases": ["R"], "symbols": ["A"]}}
+			]}`,
+			Error: "type x.E has alias x.R, which is the name of another type",
+		},
+		{
+			Schema: `{"type": "record", "name": "R", "namespace": "x", "fields": [
+				{"name": "e", "type": {"type": "enum", "name": "E", "aliases": ["Old"], "symbols": ["A"]}},
+				{"name": "f", "type": {"type": "fixed", "name": "F", "aliases": ["x.Old"], "size": 1}}
+			]}`,
+			Error: "types x.E and x.F share the alias x.Old",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			s, err := Unmarshal([]byte(test.Schema))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkError(t, Validate(s), test.Error)
+		})
+	}
+}
@@ -0,0 +1,537 @@
+package avro
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+// Avro values are represented by the following Go types:
+//
+//	null     nil
+//	boolean  bool
+//	int      int32, or any Go integer within the int32 range
+//	long     int64, or any Go integer within the int64 range
+//	float    float32 or float64
+//	double   float64 or float32
+//	bytes    []byte
+//	string   string
+//	record   map[string]interface{} keyed by field name
+//	enum     string holding the symbol
+//	array    []interface{}, or any Go slice; nil is empty
+//	map      map[string]interface{}, or any Go map with string keys; nil is empty
+//	union    the value of the selected branch, or a UnionValue or NamedValue
+//	fixed    []byte of the fixed size
+//
+// Logical types are represented by the value of their underlying type. A
+// decimal may also be a *big.Rat or *big.Int holding its numeric value, which
+// must fit the decimal's precision and scale.
+
+// UnionValue is a union value tagged with the index of the branch it belongs to.
+// Plain values select a branch by their Go type, which is ambiguous if the value
+// can represent more than one branch; a UnionValue selects it explicitly.
+type UnionValue struct {
+	Index int
+	Value interface{}
+}
+
+// NamedValue is a union value tagged with the name of the branch it belongs to:
+// the full name of a named type, such as "com.example.User", or the type name
+// of others, as WrapUnion keys them. It selects a branch explicitly like a
+// UnionValue, but by name, which is stable when branches are reordered. Records
+// in a union are ambiguous as plain maps when they share the fields present in
+// the value, and a NamedValue picks the record deterministically.
+type NamedValue struct {
+	Name  string
+	Value interface{}
+}
+
+// WrapUnion wraps a value of the union branch as the Avro JSON encoding writes
+// it: an object with a single member keyed by the branch's type name, such as
+// {"int": 5} or {"com.example.User": {...}}. Null values are not wrapped.
+// Logical types are keyed by their underlying type.
+func WrapUnion(branch Schema, v interface{}) interface{} {
+	if branchName(branch) == "null" {
+		return nil
+	}
+	return map[string]interface{}{branchName(branch): v}
+}
+
+// UnwrapUnion returns the union branch and value of a union value wrapped as
+// in the Avro JSON encoding, the inverse of WrapUnion. A nil value selects the
+// null branch.
+func UnwrapUnion(u Union, v interface{}) (Schema, interface{}, error) {
+	if v == nil {
+		for _, s := range u {
+			if branchName(s) == "null" {
+				return s, nil, nil
+			}
+		}
+		return nil, nil, fmt.Errorf("avroschema: union %v has no null branch", unionTypes(u))
+	}
+
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return nil, nil, fmt.Errorf("avroschema: union value must be null or an object with a single member, got %T", v)
+	}
+
+	var name string
+	for name, v = range m {
+	}
+
+	for _, s := range u {
+		if branchName(s) == name {
+			return s, v, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("avroschema: %v is not a branch of union %v", name, unionTypes(u))
+}
+
+// EncodeJSONBytes returns the string the Avro JSON encoding writes for a bytes
+// or fixed value: each byte is the code point of the same value, U+0000 to
+// U+00FF, rather than base64 as encoding/json writes []byte.
+func EncodeJSONBytes(b []byte) string {
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
+
+// DecodeJSONBytes returns the bytes or fixed value of a string as written by
+// the Avro JSON encoding, the inverse of EncodeJSONBytes. An error is returned
+// if the string contains a code point above U+00FF.
+func DecodeJSONBytes(s string) ([]byte, error) {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xff {
+			return nil, fmt.Errorf("avroschema: bytes value contains code point %U outside U+0000 to U+00FF", r)
+		}
+		b = append(b, byte(r))
+	}
+	return b, nil
+}
+
+// branchName returns the name that identifies a union branch: the full name of
+// named types and the type name of others.
+func branchName(s Schema) string {
+	switch x := s.(type) {
+	case Primitive:
+		return string(x)
+	case Named:
+		return x.Fullname()
+	case *Reference:
+		return x.Name
+	}
+
+	if u := Underlying(s); u != s {
+		return branchName(u)
+	}
+	return s.Type()
+}
+
+// Conforms returns an error if the value cannot represent the schema, without
+// encoding it. Union branches are selected by the same rules used for encoding.
+// If the mismatch is nested within a record, array or map, the error names the
+// path to the nonconforming value, for example items[2].price.
+func Conforms(s Schema, v interface{}) error {
+	return checkValue(s, v)
+}
+
+// pathError is a value error located at a path within a record, array or map.
+type pathError struct {
+	path string
+	err  error
+}
+
+func (e *pathError) Error() string {
+	return fmt.Sprintf("avroschema: %v: %s", e.path, strings.TrimPrefix(e.err.Error(), "avroschema: "))
+}
+
+// withPath prefixes the path of a value error with the path element of the
+// value containing it.
+func withPath(elem string, err error) error {
+	if pe, ok := err.(*pathError); ok {
+		if strings.HasPrefix(pe.path, "[") {
+			return &pathError{path: elem + pe.path, err: pe.err}
+		}
+		return &pathError{path: elem + "." + pe.path, err: pe.err}
+	}
+	return &pathError{path: elem, err: err}
+}
+
+// checkValue returns an error if the value cannot represent the schema.
+func checkValue(s Schema, v interface{}) error {
+	switch x := s.(type) {
+	case Primitive:
+		if !isPrimitiveValue(x, v) {
+			return valueError(s, v)
+		}
+		return nil
+
+	case *Record:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return valueError(s, v)
+		}
+		for _, f := range x.Fields {
+			fv, ok := m[f.Name]
+			if !ok {
+				return fmt.Errorf("avroschema: value for record %v is missing field %v", x.Name, f.Name)
+			}
+			if err := checkValue(f.Type, fv); err != nil {
+				return withPath(f.Name, err)
+			}
+		}
+		return nil
+
+	case *Enum:
+		sym, ok := v.(string)
+		if !ok {
+			return valueError(s, v)
+		}
+		if _, ok := x.Index(sym); !ok {
+			return fmt.Errorf("avroschema: %q is not a symbol of enum %v", sym, x.Name)
+		}
+		return nil
+
+	case *Array:
+		if v == nil {
+			return nil
+		}
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice {
+			return valueError(s, v)
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if err := checkValue(x.Items, rv.Index(i).Interface()); err != nil {
+				return withPath(fmt.Sprintf("[%d]", i), err)
+			}
+		}
+		return nil
+
+	case *Map:
+		if v == nil {
+			return nil
+		}
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+			return valueError(s, v)
+		}
+		for _, k := range rv.MapKeys() {
+			if err := checkValue(x.Values, rv.MapIndex(k).Interface()); err != nil {
+				return withPath(fmt.Sprintf("[%q]", k.String()), err)
+			}
+		}
+		return nil
+
+	case Union:
+		_, _, err := resolveBranch(x, v)
+		return err
+
+	case *Fixed:
+		b, ok := v.([]byte)
+		if !ok {
+			return valueError(s, v)
+		}
+		if len(b) != x.Size {
+			return fmt.Errorf("avroschema: fixed %v requires %d bytes, got %d", x.Name, x.Size, len(b))
+		}
+		return nil
+
+	case *Reference:
+		if x.Schema == nil {
+			return fmt.Errorf("avroschema: reference to %v is not resolved", x.Name)
+		}
+		return checkValue(x.Schema, v)
+
+	case *Decimal, *annotatedDecimal, *FixedDecimal:
+		switch n := v.(type) {
+		case *big.Rat:
+			return checkDecimal(x, n)
+		case *big.Int:
+			if n == nil {
+				return checkDecimal(x, nil)
+			}
+			return checkDecimal(x, new(big.Rat).SetInt(n))
+		}
+	}
+
+	// Logical types are represented by their underlying type.
+	if u := Underlying(s); u != s {
+		return checkValue(u, v)
+	}
+
+	return fmt.Errorf("avroschema: unsupported schema type %v", s.Type())
+}
+
+// checkDecimal returns an error if the number has more decimal places than the
+// decimal's scale or more digits than its precision, or does not fit in the
+// fixed size of a fixed-backed decimal whose precision is too large for it.
+func checkDecimal(s Schema, r *big.Rat) error {
+	d, _, _ := decimalOf(s)
+	if r == nil {
+		return fmt.Errorf("avroschema: nil value cannot represent decimal")
+	}
+
+	unscaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(d.Scale)))
+	if !unscaled.IsInt() {
+		return fmt.Errorf("avroschema: %v has more than %d decimal places", r.RatString(), d.Scale)
+	}
+
+	if digits := len(new(big.Int).Abs(unscaled.Num()).String()); digits > d.Precision {
+		return fmt.Errorf("avroschema: %v has %d digits, more than the decimal precision %d", r.RatString(), digits, d.Precision)
+	}
+
+	_, err := decimalBytes(s, r)
+	return err
+}
+
+// decimalBytes returns the two's-complement bytes of the unscaled number a
+// decimal value represents, as it is encoded: sign-extended to the fixed size
+// for a fixed-backed decimal and as short as possible otherwise. The value must
+// conform to the decimal's precision and scale. An error is returned if the
+// number does not fit in the fixed size.
+func decimalBytes(s Schema, v interface{}) ([]byte, error) {
+	var r *big.Rat
+	switch n := v.(type) {
+	case *big.Rat:
+		r = n
+	case *big.Int:
+		if n != nil {
+			r = new(big.Rat).SetInt(n)
+		}
+	default:
+		return v.([]byte), nil
+	}
+	if r == nil {
+		return nil, fmt.Errorf("avroschema: nil value cannot represent decimal")
+	}
+
+	d, f, _ := decimalOf(s)
+	n := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(d.Scale))).Num()
+
+	// The length holds the magnitude and a sign bit.
+	var size int
+	if n.Sign() >= 0 {
+		size = n.BitLen()/8 + 1
+	} else {
+		size = new(big.Int).Not(n).BitLen()/8 + 1
+	}
+	if f != nil {
+		if size > f.Size {
+			return nil, fmt.Errorf("avroschema: %v takes %d bytes, more than fixed %v of size %d", r.RatString(), size, f.Name, f.Size)
+		}
+		size = f.Size
+	}
+
+	if n.Sign() < 0 {
+		n = new(big.Int).Add(n, new(big.Int).Lsh(big.NewInt(1), uint(8*size)))
+	}
+	b := make([]byte, size)
+	return n.FillBytes(b), nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func isPrimitiveValue(p Primitive, v interface{}) bool {
+	switch p {
+	case Null:
+		return v == nil
+	case Boolean:
+		_, ok := v.(bool)
+		return ok
+	case Int:
+		n, ok := toInt64(v)
+		return ok && n >= math.MinInt32 && n <= math.MaxInt32
+	case Long:
+		_, ok := toInt64(v)
+		return ok
+	case Float, Double:
+		switch v.(type) {
+		case float32, float64:
+			return true
+		}
+	case Bytes:
+		_, ok := v.([]byte)
+		return ok
+	case String:
+		_, ok := v.(string)
+		return ok
+	}
+	return false
+}
+
+// isNativeValue returns true if the value's Go type is the one the schema is
+// natively represented by, as opposed to one it merely accepts.
+func isNativeValue(s Schema, v interface{}) bool {
+	switch x := s.(type) {
+	case Primitive:
+		switch x {
+		case Null:
+			return v == nil
+		case Int:
+			_, ok := v.(int32)
+			return ok
+		case Long:
+			_, ok := v.(int64)
+			return ok
+		case Float:
+			_, ok := v.(float32)
+			return ok
+		case Double:
+			_, ok := v.(float64)
+			return ok
+		}
+		return true
+
+	case *Array:
+		_, ok := v.([]interface{})
+		return ok
+
+	case *Map:
+		_, ok := v.(map[string]interface{})
+		return ok
+
+	case *Reference:
+		return x.Schema != nil && isNativeValue(x.Schema, v)
+	}
+
+	if u := Underlying(s); u != s {
+		return isNativeValue(u, v)
+	}
+
+	return true
+}
+
+// Resolve returns the index and schema of the branch the value belongs to, as
+// selected when encoding. A UnionValue or NamedValue selects its branch
+// explicitly. Otherwise
+// the branches the value conforms to are collected: a single match is selected,
+// and among several the one whose Go type natively represents it wins, such as
+// int64 for long over int. An error is returned if no branch or more than one
+// native branch matches.
+func (u Union) Resolve(v interface{}) (int, Schema, error) {
+	return resolveBranch(u, v)
+}
+
+// resolveBranch selects the union branch the value belongs to. If the value
+// can represent more than one branch, the single branch the value natively
+// represents is selected; otherwise the value is ambiguous.
+func resolveBranch(u Union, v interface{}) (int, Schema, error) {
+	if nv, ok := v.(NamedValue); ok {
+		i := branchIndex(u, nv.Name)
+		if i < 0 {
+			return -1, nil, fmt.Errorf("avroschema: %v is not a branch of union %v", nv.Name, unionTypes(u))
+		}
+		v = UnionValue{Index: i, Value: nv.Value}
+	}
+
+	if uv, ok := v.(UnionValue); ok {
+		if uv.Index < 0 || uv.Index >= len(u) {
+			return -1, nil, fmt.Errorf("avroschema: branch %d is out of range for union %v", uv.Index, unionTypes(u))
+		}
+		if err := checkValue(u[uv.Index], uv.Value); err != nil {
+			return -1, nil, err
+		}
+		return uv.Index, u[uv.Index], nil
+	}
+
+	var matches []int
+	for i, s := range u {
+		if checkValue(s, v) == nil {
+			matches = append(matches, i)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return -1, nil, fmt.Errorf("avroschema: value of type %T matches no branch of union %v", v, unionTypes(u))
+	case 1:
+		return matches[0], u[matches[0]], nil
+	}
+
+	native := -1
+	for _, i := range matches {
+		if isNativeValue(u[i], v) {
+			if native >= 0 {
+				native = -1
+				break
+			}
+			native = i
+		}
+	}
+
+	if native < 0 {
+		return -1, nil, fmt.Errorf("avroschema: value of type %T matches more than one branch of union %v", v, unionTypes(u))
+	}
+
+	return native, u[native], nil
+}
+
+// branchIndex returns the position of the union branch with the name, or -1.
+func branchIndex(u Union, name string) int {
+	for i, s := range u {
+		if branchName(s) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// unionValue returns the value of a union branch, unwrapping a UnionValue or
+// NamedValue.
+func unionValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case UnionValue:
+		return x.Value
+	case NamedValue:
+		return x.Value
+	}
+	return v
+}
+
+// unionTypes returns the type names of the union branches for error messages.
+func unionTypes(u Union) []string {
+	t := make([]string, len(u))
+	for i, s := range u {
+		t[i] = s.Type()
+	}
+	return t
+}
+
+func valueError(s Schema, v interface{}) error {
+	return fmt.Errorf("avroschema: value of type %T cannot represent %v", v, s.Type())
+}
+
+// toInt64 converts any Go integer to an int64, reporting false if the value is
+// not an integer or is out of range.
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint:
+		return int64(n), uint64(n) <= math.MaxInt64
+	case uint8:
+		return int64(n), true
+	case uint16:
+		return int64(n), true
+	case uint32:
+		return int64(n), true
+	case uint64:
+		return int64(n), n <= math.MaxInt64
+	}
+	return 0, false
+}
@@ -0,0 +1,329 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestConforms(t *testing.T) {
+	item := &Record{
+		Name: "Item",
+		Fields: []*Field{
+			{Name: "sku", Type: String},
+			{Name: "price", Type: Double},
+		},
+	}
+	order := &Record{
+		Name: "Order",
+		Fields: []*Field{
+			{Name: "id", Type: Long},
+			{Name: "items", Type: &Array{Items: item}},
+			{Name: "tags", Type: &Map{Values: String}},
+			{Name: "note", Type: Union{Null, String}},
+		},
+	}
+
+	valid := map[string]interface{}{
+		"id": int64(1),
+		"items": []interface{}{
+			map[string]interface{}{"sku": "a", "price": 1.5},
+		},
+		"tags": map[string]string{"channel": "web"},
+		"note": nil,
+	}
+	if err := Conforms(order, valid); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Value interface{}
+		Err   string
+	}{
+		{
+			map[string]interface{}{
+				"id": "1", "items": []interface{}{}, "tags": map[string]string{}, "note": nil,
+			},
+			"avroschema: id: value of type string cannot represent long",
+		},
+		{
+			map[string]interface{}{
+				"id": 1,
+				"items": []interface{}{
+					map[string]interface{}{"sku": "a", "price": 1.5},
+					map[string]interface{}{"sku": "b", "price": "free"},
+				},
+				"tags": map[string]string{},
+				"note": nil,
+			},
+			"avroschema: items[1].price: value of type string cannot represent double",
+		},
+		{
+			map[string]interface{}{
+				"id": 1, "items": []interface{}{}, "tags": map[string]interface{}{"channel": 1}, "note": nil,
+			},
+			`avroschema: tags["channel"]: value of type int cannot represent string`,
+		},
+		{
+			map[string]interface{}{
+				"id": 1, "items": []interface{}{map[string]interface{}{"sku": "a"}}, "tags": map[string]string{}, "note": nil,
+			},
+			"avroschema: items[0]: value for record Item is missing field price",
+		},
+		{
+			map[string]interface{}{
+				"id": 1, "items": []interface{}{}, "tags": map[string]string{}, "note": 2,
+			},
+			"avroschema: note: value of type int matches no branch of union [null string]",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			checkError(t, Conforms(order, test.Value), test.Err)
+		})
+	}
+}
+
+func TestConformsDecimal(t *testing.T) {
+	d := &Decimal{Precision: 38, Scale: 2}
+	fixed := &FixedDecimal{Precision: 38, Scale: 2, Fixed: &Fixed{Name: "money", Size: 16}}
+
+	max, _ := new(big.Int).SetString(strings.Repeat("9", 38), 10)
+	rat := func(s string) *big.Rat {
+		r, _ := new(big.Rat).SetString(s)
+		return r
+	}
+
+	tests := []struct {
+		Schema Schema
+		Value  interface{}
+		Err    string
+	}{
+		{d, rat("123.45"), ""},
+		{d, rat("-0.5"), ""},
+		{d, big.NewInt(42), ""},
+		{d, new(big.Rat).SetFrac(max, big.NewInt(100)), ""},
+		{fixed, new(big.Rat).SetFrac(max, big.NewInt(100)), ""},
+		{d, []byte{0x30, 0x39}, ""},
+		{d, rat("1.234"), "617/500 has more than 2 decimal places"},
+		{d, rat("1/3"), "1/3 has more than 2 decimal places"},
+		{d, max, "has 40 digits, more than the decimal precision 38"},
+		{d, (*big.Rat)(nil), "nil value cannot represent decimal"},
+		{d, (*big.Int)(nil), "nil value cannot represent decimal"},
+		{fixed, (*big.Int)(nil), "nil value cannot represent decimal"},
+		{d, "123.45", "value of type string cannot represent bytes"},
+
+		// The precision of a fixed-backed decimal may be too large for its
+		// size, as Validate reports.
+		{&FixedDecimal{Precision: 4, Scale: 2, Fixed: &Fixed{Name: "F", Size: 1}}, rat("99.99"), "9999/100 takes 2 bytes, more than fixed F of size 1"},
+		{&FixedDecimal{Precision: 4, Scale: 2, Fixed: &Fixed{Name: "F", Size: 1}}, rat("-1.28"), ""},
+
+		// Big numbers only represent decimals.
+		{Bytes, big.NewInt(1), "value of type *big.Int cannot represent bytes"},
+		{Long, big.NewInt(1), "value of type *big.Int cannot represent long"},
+		{&Fixed{Name: "F", Size: 16}, rat("1"), "value of type *big.Rat cannot represent fixed"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			checkError(t, Conforms(test.Schema, test.Value), test.Err)
+		})
+	}
+}
+
+func TestDecimalBytes(t *testing.T) {
+	d := &Decimal{Precision: 9, Scale: 2}
+	fixed := &FixedDecimal{Precision: 9, Scale: 2, Fixed: &Fixed{Name: "money", Size: 4}}
+
+	tests := []struct {
+		Decimal Schema
+		Value   *big.Rat
+		Bytes   []byte
+	}{
+		{d, big.NewRat(0, 1), []byte{0x00}},
+		{d, big.NewRat(127, 100), []byte{0x7f}},
+		{d, big.NewRat(128, 100), []byte{0x00, 0x80}},
+		{d, big.NewRat(-1, 100), []byte{0xff}},
+		{d, big.NewRat(-128, 100), []byte{0x80}},
+		{d, big.NewRat(-129, 100), []byte{0xff, 0x7f}},
+		{d, big.NewRat(12345, 100), []byte{0x30, 0x39}},
+		{fixed, big.NewRat(12345, 100), []byte{0x00, 0x00, 0x30, 0x39}},
+		{fixed, big.NewRat(-1, 100), []byte{0xff, 0xff, 0xff, 0xff}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			if b, _ := decimalBytes(test.Decimal, test.Value); !bytes.Equal(b, test.Bytes) {
+				t.Errorf("expected %x, got %x", test.Bytes, b)
+			}
+		})
+	}
+}
+
+func TestUnionResolve(t *testing.T) {
+	u := Union{Null, Int, Long, String, &Array{Items: Double}}
+
+	tests := []struct {
+		Value interface{}
+		Index int
+		Err   string
+	}{
+		{Value: nil, Index: 0},
+		{Value: int32(1), Index: 1},
+		{Value: int64(1), Index: 2},
+		{Value: "a", Index: 3},
+		{Value: []float64{1}, Index: 4},
+		{Value: UnionValue{Index: 2, Value: 1}, Index: 2},
+		{Value: 1, Err: "matches more than one branch of union [null int long string array]"},
+		{Value: true, Err: "value of type bool matches no branch of union [null int long string array]"},
+		{Value: UnionValue{Index: 5}, Err: "branch 5 is out of range"},
+		{Value: NamedValue{Name: "long", Value: 1}, Index: 2},
+		{Value: NamedValue{Name: "array", Value: []float64{}}, Index: 4},
+		{Value: NamedValue{Name: "boolean", Value: true}, Err: "boolean is not a branch of union [null int long string array]"},
+		{Value: NamedValue{Name: "int", Value: "a"}, Err: "cannot represent int"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			i, s, err := u.Resolve(test.Value)
+			checkError(t, err, test.Err)
+			if test.Err != "" {
+				return
+			}
+			if i != test.Index || !Equal(s, u[test.Index]) {
+				t.Errorf("expected branch %d, got %d (%v)", test.Index, i, s)
+			}
+		})
+	}
+}
+
+func TestUnionResolveRecords(t *testing.T) {
+	newRecord := func(name string) *Record {
+		return &Record{
+			Name:      name,
+			Namespace: "com.example",
+			Fields:    []*Field{{Name: "id", Type: Long}},
+		}
+	}
+	u := Union{newRecord("User"), newRecord("Group")}
+	v := map[string]interface{}{"id": int64(1)}
+
+	// A plain map matches both records.
+	_, _, err := u.Resolve(v)
+	checkError(t, err, "matches more than one branch")
+
+	i, _, err := u.Resolve(NamedValue{Name: "com.example.Group", Value: v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i != 1 {
+		t.Errorf("expected branch 1, got %d", i)
+	}
+
+	n, err := EncodedSize(u, NamedValue{Name: "com.example.Group", Value: v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 bytes, got %d", n)
+	}
+}
+
+func TestWrapUnion(t *testing.T) {
+	user := &Record{Name: "User", Namespace: "com.example", Fields: []*Field{{Name: "name", Type: String}}}
+	u := Union{Null, Int, TimestampMillis, &Array{Items: String}, user}
+
+	tests := []struct {
+		Branch Schema
+		Value  interface{}
+		JSON   string
+	}{
+		{Null, nil, `null`},
+		{Int, 5, `{"int":5}`},
+		{TimestampMillis, 1500, `{"long":1500}`},
+		{u[3], []interface{}{"a"}, `{"array":["a"]}`},
+		{user, map[string]interface{}{"name": "ann"}, `{"com.example.User":{"name":"ann"}}`},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			w := WrapUnion(test.Branch, test.Value)
+
+			b, err := json.Marshal(w)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != test.JSON {
+				t.Errorf("expected %s, got %s", test.JSON, b)
+			}
+
+			var decoded interface{}
+			if err := json.Unmarshal(b, &decoded); err != nil {
+				t.Fatal(err)
+			}
+
+			s, v, err := UnwrapUnion(u, decoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s != test.Branch {
+				t.Errorf("expected branch %v, got %v", test.Branch, s)
+			}
+			if vb, _ := json.Marshal(v); string(vb) != mustMarshal(t, test.Value) {
+				t.Errorf("expected value %v, got %v", test.Value, v)
+			}
+		})
+	}
+
+	_, _, err := UnwrapUnion(u, map[string]interface{}{"string": "x"})
+	checkError(t, err, "avroschema: string is not a branch of union")
+
+	_, _, err = UnwrapUnion(Union{Int}, nil)
+	checkError(t, err, "avroschema: union [int] has no null branch")
+
+	_, _, err = UnwrapUnion(u, map[string]interface{}{"int": 1, "long": 2})
+	checkError(t, err, "must be null or an object with a single member")
+}
+
+func TestJSONBytes(t *testing.T) {
+	b := []byte{0x00, 0x7f, 0x80, 0xff}
+
+	s := EncodeJSONBytes(b)
+	if exp := "\u0000\u007f\u0080\u00ff"; s != exp {
+		t.Errorf("expected %+q, got %+q", exp, s)
+	}
+
+	// Both the UTF-8 form written by encoding/json and the escaped form
+	// written by other implementations decode to the same bytes.
+	for _, in := range []string{mustMarshal(t, s), `"\u0000\u007f\u0080\u00ff"`} {
+		var v string
+		if err := json.Unmarshal([]byte(in), &v); err != nil {
+			t.Fatal(err)
+		}
+		got, err := DecodeJSONBytes(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, b) {
+			t.Errorf("%s: expected %x, got %x", in, b, got)
+		}
+	}
+
+	if _, err := DecodeJSONBytes("\u0100"); err == nil {
+		t.Error("expected error for code point above U+00FF")
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) string {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}